@@ -0,0 +1,213 @@
+// Package archive packs directories into tar.gz streams and unpacks them
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+// Pack writes the contents of dir to w as a gzipped tar. Entry names are
+// slash-separated paths relative to dir. Files and folders whose name is
+// in ignore are skipped.
+func Pack(w io.Writer, dir string, ignore []string) error {
+	aw := NewWriter(w)
+	if err := aw.AddDir(dir, "", ignore); err != nil {
+		return err
+	}
+	return aw.Close()
+}
+
+// Writer builds a gzipped tar from several files and directories
+type Writer struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+// NewWriter returns a Writer writing to w. Close must be called to
+// finish the archive.
+func NewWriter(w io.Writer) *Writer {
+	gz := gzip.NewWriter(w)
+	return &Writer{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+// AddDir adds the contents of dir below the slash-separated prefix, which
+// may be empty. Files and folders whose name is in ignore are skipped.
+func (w *Writer) AddDir(dir, prefix string, ignore []string) error {
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		if utils.ShouldIgnore(d.Name(), ignore) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		return addEntry(w.tw, p, path.Join(prefix, filepath.ToSlash(rel)), d)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pack %s: %w", dir, err)
+	}
+	return nil
+}
+
+// AddFile adds the file src under the slash-separated name
+func (w *Writer) AddFile(src, name string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return addEntry(w.tw, src, name, fs.FileInfoToDirEntry(info))
+}
+
+// AddBytes adds data as a regular file under the slash-separated name
+func (w *Writer) AddBytes(name string, data []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+// Close finishes the archive; it does not close the underlying writer
+func (w *Writer) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+func addEntry(tw *tar.Writer, src, name string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+
+	// Symlinks and other special files are not part of templates
+	if !info.Mode().IsRegular() && !info.IsDir() {
+		return nil
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	// Keep archives reproducible and free of local account names
+	hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Unpack extracts a gzipped tar from r into dest. Entries that would end up
+// outside dest are rejected.
+func Unpack(r io.Reader, dest string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+	defer gz.Close()
+
+	return Walk(tar.NewReader(gz), func(name string, hdr *tar.Header, body io.Reader) error {
+		target, err := SafeJoin(dest, name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			return os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			return writeFile(target, body, hdr.FileInfo().Mode().Perm())
+		default:
+			// Links and devices are never created
+			return nil
+		}
+	})
+}
+
+// Walk calls fn for every entry of tr with its cleaned, slash-separated name
+func Walk(tr *tar.Reader, fn func(name string, hdr *tar.Header, body io.Reader) error) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid archive: %w", err)
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if name == "" {
+			continue
+		}
+		if err := fn(name, hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// SafeJoin joins a slash-separated archive name onto dest, refusing names
+// that escape dest
+func SafeJoin(dest, name string) (string, error) {
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dest, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry '%s' points outside the destination", name)
+	}
+	return target, nil
+}
+
+func writeFile(target string, body io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if mode == 0 {
+		mode = 0644
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,337 @@
+// Package bundle reads and writes .blpack files, single-file archives for
+// handing snippets and stacks to someone else. A bundle is a gzipped tar
+// holding the resources in the store layout (snippets/<lang>/<full name>,
+// stacks/<full name>/...) and a manifest.json listing every resource with
+// its metadata and the size and SHA-256 of each of its files. A signed
+// bundle also holds manifest.sig, an ed25519 signature over the manifest.
+package bundle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rishiyaduwanshi/boiler/internal/archive"
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+)
+
+const (
+	// Ext is the file extension of a bundle
+	Ext = ".blpack"
+	// ManifestFile is the name of the manifest inside a bundle
+	ManifestFile = "manifest.json"
+	// SignatureFile is the name of the manifest signature inside a bundle
+	SignatureFile = "manifest.sig"
+	// FormatVersion is the manifest format written by this version
+	FormatVersion = 1
+
+	KindSnippet = "snippet"
+	KindStack   = "stack"
+)
+
+// Manifest describes the contents of a bundle
+type Manifest struct {
+	Format    int        `json:"format"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Resources []Resource `json:"resources"`
+}
+
+// Resource is one snippet or stack version in a bundle
+type Resource struct {
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Extension   string    `json:"extension,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// Path is the snippet file or stack folder inside the bundle
+	Path  string `json:"path"`
+	Files []File `json:"files"`
+
+	// Src is the file or folder to pack; only used by Write
+	Src string `json:"-"`
+}
+
+// File is a single file of a resource
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// FullName returns the versioned name, e.g. logger@1.0.0.js
+func (r Resource) FullName() string {
+	return store.FormatResourceName(r.Name, r.Version, r.Extension)
+}
+
+// Key returns the resource key used for tags, e.g. logger.js
+func (r Resource) Key() string {
+	return store.ResourceKey(r.Name, r.Extension)
+}
+
+// bundlePath is where a resource lives inside a bundle
+func (r Resource) bundlePath() string {
+	if r.Kind == KindStack {
+		return path.Join("stacks", r.FullName())
+	}
+	return path.Join("snippets", strings.TrimPrefix(r.Extension, "."), r.FullName())
+}
+
+// Write packs resources into a bundle at dest. Path and Files of each
+// resource are filled in from its Src. The manifest is signed with key
+// unless it is nil.
+func Write(dest string, resources []Resource, key ed25519.PrivateKey) error {
+	manifest := Manifest{Format: FormatVersion, CreatedAt: time.Now().UTC()}
+	for _, r := range resources {
+		if err := validate(r); err != nil {
+			return err
+		}
+		r.Path = r.bundlePath()
+		files, err := listFiles(r.Src, r.Path, r.Kind == KindStack)
+		if err != nil {
+			return fmt.Errorf("failed to read '%s': %w", r.FullName(), err)
+		}
+		r.Files = files
+		manifest.Resources = append(manifest.Resources, r)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	var buf bytes.Buffer
+	aw := archive.NewWriter(&buf)
+	if err := aw.AddBytes(ManifestFile, data); err != nil {
+		return err
+	}
+	if key != nil {
+		sig, err := json.MarshalIndent(signing.Sign(key, signing.BundleMessage(data)), "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := aw.AddBytes(SignatureFile, sig); err != nil {
+			return err
+		}
+	}
+	for _, r := range manifest.Resources {
+		if r.Kind == KindStack {
+			err = aw.AddDir(r.Src, r.Path, nil)
+		} else {
+			err = aw.AddFile(r.Src, r.Path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := aw.Close(); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(dest, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// listFiles hashes the file src, or every file below the folder src, and
+// names them below prefix
+func listFiles(src, prefix string, dir bool) ([]File, error) {
+	if !dir {
+		f, err := hashFile(src, prefix)
+		if err != nil {
+			return nil, err
+		}
+		return []File{f}, nil
+	}
+
+	var files []File
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		f, err := hashFile(p, path.Join(prefix, filepath.ToSlash(rel)))
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		return nil
+	})
+	return files, err
+}
+
+func hashFile(src, name string) (File, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return File{}, err
+	}
+	sum, err := store.FileChecksum(src)
+	if err != nil {
+		return File{}, err
+	}
+	return File{Path: name, Size: info.Size(), SHA256: sum}, nil
+}
+
+// Bundle is an opened bundle, unpacked into a temporary directory
+type Bundle struct {
+	Manifest Manifest
+	// Signature is nil for unsigned bundles
+	Signature *signing.Signature
+
+	manifest []byte
+	dir      string
+}
+
+// Open unpacks the bundle at src and verifies every file against the
+// manifest. Files that are missing, changed or not listed are an error.
+// Close removes the unpacked files.
+func Open(src string) (*Bundle, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer f.Close()
+
+	dir, err := os.MkdirTemp("", "boiler-bundle-")
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{dir: dir}
+
+	if err := archive.Unpack(f, dir); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to unpack %s: %w", src, err)
+	}
+	if err := b.load(); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("invalid bundle %s: %w", src, err)
+	}
+	return b, nil
+}
+
+// Path returns the unpacked snippet file or stack folder of r
+func (b *Bundle) Path(r Resource) string {
+	return filepath.Join(b.dir, filepath.FromSlash(r.Path))
+}
+
+// Verify checks the bundle's signature against the trusted keys and returns
+// the name of the key that signed it
+func (b *Bundle) Verify(trusted []signing.TrustedKey) (string, error) {
+	return signing.Verify(b.Signature, signing.BundleMessage(b.manifest), trusted)
+}
+
+// Close removes the unpacked files
+func (b *Bundle) Close() error {
+	return os.RemoveAll(b.dir)
+}
+
+func (b *Bundle) load() error {
+	data, err := os.ReadFile(filepath.Join(b.dir, ManifestFile))
+	if err != nil {
+		return fmt.Errorf("missing %s", ManifestFile)
+	}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", ManifestFile, err)
+	}
+	b.manifest = data
+
+	if sig, err := os.ReadFile(filepath.Join(b.dir, SignatureFile)); err == nil {
+		if err := json.Unmarshal(sig, &b.Signature); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", SignatureFile, err)
+		}
+	}
+	if b.Manifest.Format < 1 || b.Manifest.Format > FormatVersion {
+		return fmt.Errorf("unsupported format %d; update Boiler with 'bl self update'", b.Manifest.Format)
+	}
+
+	listed := map[string]bool{ManifestFile: true, SignatureFile: true}
+	seen := make(map[string]bool)
+	for _, r := range b.Manifest.Resources {
+		if err := validate(r); err != nil {
+			return err
+		}
+		if r.Path != r.bundlePath() {
+			return fmt.Errorf("'%s' has unexpected path '%s'", r.FullName(), r.Path)
+		}
+		if seen[r.Kind+":"+r.FullName()] {
+			return fmt.Errorf("'%s' is listed twice", r.FullName())
+		}
+		seen[r.Kind+":"+r.FullName()] = true
+
+		for _, file := range r.Files {
+			if file.Path != r.Path && !strings.HasPrefix(file.Path, r.Path+"/") {
+				return fmt.Errorf("file '%s' is outside of '%s'", file.Path, r.FullName())
+			}
+			if err := b.verify(file); err != nil {
+				return err
+			}
+			listed[file.Path] = true
+		}
+	}
+
+	// Nothing may come along that the manifest does not vouch for
+	return filepath.WalkDir(b.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(b.dir, p)
+		if err != nil {
+			return err
+		}
+		if !listed[filepath.ToSlash(rel)] {
+			return fmt.Errorf("file '%s' is not in the manifest", filepath.ToSlash(rel))
+		}
+		return nil
+	})
+}
+
+func (b *Bundle) verify(file File) error {
+	p, err := archive.SafeJoin(b.dir, file.Path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return fmt.Errorf("file '%s' is missing", file.Path)
+	}
+	sum, err := store.FileChecksum(p)
+	if err != nil {
+		return err
+	}
+	if info.Size() != file.Size || sum != file.SHA256 {
+		return fmt.Errorf("checksum mismatch for '%s'", file.Path)
+	}
+	return nil
+}
+
+// validate rejects names that cannot be stored as a single path element
+func validate(r Resource) error {
+	if r.Kind != KindSnippet && r.Kind != KindStack {
+		return fmt.Errorf("unknown kind '%s'", r.Kind)
+	}
+	if r.Name == "" || strings.ContainsAny(r.Name, `/\@`) || strings.HasPrefix(r.Name, ".") {
+		return fmt.Errorf("invalid name '%s'", r.Name)
+	}
+	if _, err := store.ParseVersion(r.Version); err != nil {
+		return fmt.Errorf("invalid version for '%s': %w", r.Name, err)
+	}
+	if r.Kind == KindSnippet && (!strings.HasPrefix(r.Extension, ".") || strings.ContainsAny(r.Extension, `/\`)) {
+		return fmt.Errorf("invalid extension for '%s'", r.Name)
+	}
+	return nil
+}
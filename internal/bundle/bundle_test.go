@@ -0,0 +1,109 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/archive"
+)
+
+func writeTestBundle(t *testing.T) (string, []Resource) {
+	t.Helper()
+	dir := t.TempDir()
+
+	snippet := filepath.Join(dir, "logger.js")
+	os.WriteFile(snippet, []byte("console.log(1)\n"), 0644)
+	stack := filepath.Join(dir, "express")
+	os.MkdirAll(filepath.Join(stack, "src"), 0755)
+	os.WriteFile(filepath.Join(stack, "src", "app.js"), []byte("app()\n"), 0644)
+	os.WriteFile(filepath.Join(stack, "boiler.stack.json"), []byte(`{"id":"express","version":"1.0.0"}`), 0644)
+
+	resources := []Resource{
+		{Kind: KindSnippet, Name: "logger", Version: "1.2.0", Extension: ".js", Author: "Ann", Tags: []string{"stable"}, Src: snippet},
+		{Kind: KindStack, Name: "express", Version: "1.0.0", Src: stack},
+	}
+	dest := filepath.Join(dir, "team"+Ext)
+	if err := Write(dest, resources, nil); err != nil {
+		t.Fatal(err)
+	}
+	return dest, resources
+}
+
+func TestWriteOpenRoundTrip(t *testing.T) {
+	dest, _ := writeTestBundle(t)
+
+	b, err := Open(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if len(b.Manifest.Resources) != 2 {
+		t.Fatalf("got %d resources, want 2", len(b.Manifest.Resources))
+	}
+	snippet, stack := b.Manifest.Resources[0], b.Manifest.Resources[1]
+	if snippet.FullName() != "logger@1.2.0.js" || snippet.Author != "Ann" || len(snippet.Tags) != 1 {
+		t.Fatalf("snippet = %+v", snippet)
+	}
+	if data, _ := os.ReadFile(b.Path(snippet)); string(data) != "console.log(1)\n" {
+		t.Fatalf("snippet content = %q", data)
+	}
+	if len(stack.Files) != 2 {
+		t.Fatalf("stack files = %v, want 2", stack.Files)
+	}
+	if _, err := os.Stat(filepath.Join(b.Path(stack), "src", "app.js")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	dest, _ := writeTestBundle(t)
+
+	tests := map[string]func(dir string){
+		"changed file": func(dir string) {
+			os.WriteFile(filepath.Join(dir, "snippets", "js", "logger@1.2.0.js"), []byte("evil\n"), 0644)
+		},
+		"extra file": func(dir string) {
+			os.WriteFile(filepath.Join(dir, "stacks", "express@1.0.0", "src", "evil.js"), []byte("evil\n"), 0644)
+		},
+		"missing file": func(dir string) {
+			os.Remove(filepath.Join(dir, "stacks", "express@1.0.0", "src", "app.js"))
+		},
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			f, err := os.Open(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = archive.Unpack(f, dir)
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tamper(dir)
+
+			bad := filepath.Join(t.TempDir(), "bad"+Ext)
+			out, err := os.Create(bad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := archive.Pack(out, dir, nil); err != nil {
+				t.Fatal(err)
+			}
+			out.Close()
+
+			b, err := Open(bad)
+			if err == nil {
+				b.Close()
+				t.Fatal("Open() succeeded on a tampered bundle")
+			}
+			if !strings.Contains(err.Error(), "invalid bundle") {
+				t.Fatalf("Open() error = %v", err)
+			}
+		})
+	}
+}
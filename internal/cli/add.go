@@ -1,10 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
 	"github.com/rishiyaduwanshi/boiler/internal/store"
 	"github.com/rishiyaduwanshi/boiler/internal/utils"
 	"github.com/spf13/cobra"
@@ -19,14 +23,87 @@ The command copies resources from your store. For snippets with a single version
 you can use just the name (e.g., 'errorHandler' will auto-select version 1).
 For multiple versions, you'll be prompted to choose.
 
+Versions, Constraints and Tags:
+  name@1.4.2     Exactly this version
+  name@^2        Highest 2.x.x version
+  name@~1.4      Highest 1.4.x version
+  name@>=1.2     Highest version from 1.2.0 up
+  name@latest    Highest stored version
+  name@stable    Version the 'stable' tag points at (see 'bl tag')
+  Anything after '@' resolves without prompting, so scripts and CI get a
+  predictable version.
+
 Template Variables:
   Snippets can contain template variables using the format: bl__VAR_NAME
   When adding a snippet with variables, you'll be prompted to provide values:
     - Default values are shown in brackets (from __var declarations)
     - Press Enter to use default or type a custom value
+    - Invalid values are rejected and asked for again
     - Variables are replaced and metadata comments are removed in the final file
 
-Stacks are also versioned and can be added by name or with explicit version.`,
+  Variables are declared in a comment:
+    __var bl__NAME[:type] [= default] [-- description]
+  Types are string (default), int, bool, url, path, enum(a|b|c) and
+  string(/regex/). A variable without "= default" is required.
+    // __var bl__PORT:int = 3000 -- Port the server listens on
+    // __var bl__ENV:enum(dev|staging|prod) = dev
+    // __var bl__SERVICE:string(/^[a-z][a-z0-9-]*$/) -- Service name
+
+  One value can be written in several forms with pipes. The variable is
+  asked for once and each form is derived from it:
+    bl__ENTITY|pascal    UserProfile     bl__ENTITY|snake     user_profile
+    bl__ENTITY|camel     userProfile     bl__ENTITY|kebab     user-profile
+    bl__ENTITY|constant  USER_PROFILE    bl__ENTITY|title     User Profile
+    bl__ENTITY|plural    adds an English plural; also |upper and |lower
+  Pipes chain: bl__ENTITY|plural|kebab gives user-profiles.
+
+  Reserved variables are filled in without asking and recorded like the
+  others: bl__YEAR, bl__DATE, bl__GIT_USER, bl__GIT_EMAIL, bl__DIR (name of
+  the destination directory), bl__GO_MODULE (from go.mod), bl__NPM_PACKAGE
+  (from package.json) and bl__UUID. Declaring one makes its value the
+  default instead. Defaults can be computed from variables declared before
+  them, and from reserved ones:
+    // __var bl__ROUTE = /{{bl__ENTITY|plural|kebab}}
+
+  Lines can depend on bool and enum variables. The directive lines are
+  removed like __var lines:
+    // __if bl__USE_REDIS           also !bl__X, bl__DB == pg|mysql, !=
+    // __elif bl__DB == sqlite
+    // __else
+    // __endif
+
+  Values can also be given up front, so scripts and CI never block on a
+  prompt. Later sources win:
+    BL_VAR_API_URL=...          Environment variable, sets bl__API_URL
+    --values vars.json|.env     File of NAME=value lines or a JSON object
+    --set bl__API_URL=...       Single value, repeatable
+  Only variables left without a value are prompted for. --no-input never
+  prompts: defaults are used as they are and add fails, listing them, if
+  required variables have no value.
+
+Stacks are also versioned and can be added by name or with explicit version.
+
+Local and Global Stores:
+  Resources are looked up in the project-local .boiler/ store first (found by
+  walking up from the current directory), then in the global store. Use
+  --local or --global to use only one of them.
+
+Integrity:
+  The SHA-256 of every file is recorded when a resource is stored. Before
+  copying, add checks the stored files against it and refuses if anything was
+  edited, removed or added in the store since. --skip-verify adds it anyway;
+  --force only overwrites existing files.
+
+Lock File:
+  Every add is recorded in boiler.lock.json with the version, checksum, the
+  files written and the variable values used. It sits next to the
+  project-local store, or in the working directory; 'bl install' replays it.
+
+Remote Registry:
+  --remote fetches the resource from the registry in boiler.conf.json. The
+  download is checked against the registry's SHA-256 checksum and kept in a
+  cache under the Boiler root. Versions, constraints and tags work the same;
+  without a version the latest published one is used.`,
 	Example: `  # Add snippet (auto-detects if single version)
   bl add errorHandler
 
@@ -36,17 +113,34 @@ Stacks are also versioned and can be added by name or with explicit version.`,
   #          bl__API_KEY [your-key]: abc123xyz
   # Output: Clean file with variables replaced, no metadata comments
 
+  # Add without prompts, e.g. in CI
+  bl add apiClient@latest.js --set bl__API_URL=https://api.example.com --no-input
+  BL_VAR_API_KEY=abc123xyz bl add apiClient@latest.js --values vars.env --no-input
+
   # Add specific version
   bl add logger@2.js
 
+  # Add highest 2.x version, or the version tagged stable
+  bl add logger@^2.js
+  bl add logger@stable.js
+
   # Add to specific directory
   bl add config --to ./src/utils
 
   # Add stack
   bl add express-api@1
 
-  # Force overwrite
-  bl add middleware --force`,
+  # Only use the project-local store
+  bl add logger --local
+
+  # Fetch from the registry
+  bl add logger@^2.js --remote
+
+  # Overwrite existing files
+  bl add middleware --force
+
+  # Add despite a checksum mismatch
+  bl add middleware --skip-verify`,
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		resource := args[0]
@@ -60,27 +154,36 @@ Stacks are also versioned and can be added by name or with explicit version.`,
 }
 
 func addResource(resource string) error {
-	st, err := utils.LoadStore(cfg.Paths.Store)
-	if err != nil {
-		return err
-	}
-
 	destPath := addTo
 	if destPath == "" {
 		destPath = "."
 	}
 
+	if addRemote {
+		return addRemoteResource(resource, destPath)
+	}
+
 	// Parse resource name to extract parts
 	baseName, version, ext := store.ParseResourceName(resource)
 
+	// Look in the local store first, then the global one
+	scopes, err := readScopes(addLocal && !addBoth, addGlobal && !addBoth)
+	if err != nil {
+		return err
+	}
+	st, scope, err := lookupStore(scopes, baseName, ext)
+	if err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Using %s store for %s", scope.Name, resource))
+
+	// An explicit version, constraint or tag resolves without prompting
+	if version != "" {
+		return addResolved(st, baseName, version, ext, destPath)
+	}
+
 	// If it's a snippet (has extension)
 	if ext != "" {
-		// If version is explicitly provided, use it directly
-		if version != "" {
-			fullName := baseName + "@" + version + ext
-			return addSnippet(st, fullName, destPath)
-		}
-
 		// No version specified - find matching snippets by name and extension
 		matchingSnippets := findMatchingSnippetsByNameAndExt(st, baseName, ext)
 		
@@ -88,36 +191,25 @@ func addResource(resource string) error {
 			return fmt.Errorf(utils.ErrResourceNotFound, "snippet", resource)
 		}
 
-		// If only one version exists, use it automatically
-		if len(matchingSnippets) == 1 {
-			return addSnippet(st, matchingSnippets[0], destPath)
-		}
-
-		// Multiple versions - prompt user to choose
-		fmt.Printf("Multiple versions found for '%s%s':\n", baseName, ext)
-		for i, name := range matchingSnippets {
-			fmt.Printf("  %d. %s\n", i+1, name)
-		}
-
-		choice, err := utils.Prompt(fmt.Sprintf("Enter version number (1-%d): ", len(matchingSnippets)))
+		selected, err := chooseVersion(baseName+ext, matchingSnippets)
 		if err != nil {
-			return fmt.Errorf("failed to read input: %w", err)
+			return err
 		}
-
-		var selectedIdx int
-		fmt.Sscanf(choice, "%d", &selectedIdx)
-		if selectedIdx < 1 || selectedIdx > len(matchingSnippets) {
-			return fmt.Errorf("invalid choice")
-		}
-
-		return addSnippet(st, matchingSnippets[selectedIdx-1], destPath)
+		return addSnippet(st, selected, destPath)
 	}
 
 	// No extension - could be stack or snippet name without version/extension
 	// First check if it exists as a stack
-	_, stackExists := st.GetStack(resource)
-	if stackExists {
-		return addStack(st, resource, destPath)
+	if stackVersions := st.GetAllStackVersions(baseName); len(stackVersions) > 0 {
+		names := make([]string, len(stackVersions))
+		for i, v := range stackVersions {
+			names[i] = store.FormatResourceName(baseName, v, "")
+		}
+		selected, err := chooseVersion(baseName, names)
+		if err != nil {
+			return err
+		}
+		return addStack(st, selected, destPath)
 	}
 
 	// Not a stack, try to find matching snippets by base name only
@@ -126,30 +218,101 @@ func addResource(resource string) error {
 		return fmt.Errorf(utils.ErrResourceNotFound, "stack or snippet", resource)
 	}
 
+	selected, err := chooseVersion(baseName, matchingSnippets)
+	if err != nil {
+		return err
+	}
+	return addSnippet(st, selected, destPath)
+}
+
+// addResolved adds the version of a resource selected by an exact version,
+// a constraint such as ^2 or a tag such as stable or latest
+func addResolved(st *store.Store, baseName, spec, ext, destPath string) error {
+	name, isStack, err := resolveResource(st, baseName, spec, ext)
+	if err != nil {
+		return err
+	}
+	if isStack {
+		return addStack(st, name, destPath)
+	}
+	return addSnippet(st, name, destPath)
+}
+
+// resolveResource returns the full name of the stored version selected by
+// spec and whether it is a stack. Without an extension, stacks are tried
+// first, then snippets as long as only one extension exists for the name.
+func resolveResource(st *store.Store, baseName, spec, ext string) (string, bool, error) {
+	if ext != "" {
+		entry, err := st.ResolveSnippet(baseName, ext, spec)
+		if err != nil {
+			return "", false, err
+		}
+		return entry.FullName(), false, nil
+	}
+
+	if len(st.GetAllStackVersions(baseName)) > 0 {
+		entry, err := st.ResolveStack(baseName, spec)
+		if err != nil {
+			return "", true, err
+		}
+		return entry.FullName(), true, nil
+	}
+
+	// A snippet named without extension is fine as long as it is unambiguous
+	var exts []string
+	for _, name := range findMatchingSnippets(st, baseName) {
+		_, _, snippetExt := store.ParseResourceName(name)
+		if !slices.Contains(exts, snippetExt) {
+			exts = append(exts, snippetExt)
+		}
+	}
+	switch len(exts) {
+	case 0:
+		return "", false, fmt.Errorf(utils.ErrResourceNotFound, "stack or snippet", baseName)
+	case 1:
+		ext = exts[0]
+	default:
+		return "", false, fmt.Errorf("'%s' exists as %s. Add the extension, e.g. %s@%s%s",
+			baseName, strings.Join(exts, ", "), baseName, spec, exts[0])
+	}
+
+	entry, err := st.ResolveSnippet(baseName, ext, spec)
+	if err != nil {
+		return "", false, err
+	}
+	return entry.FullName(), false, nil
+}
+
+// chooseVersion returns the only name, or prompts the user to pick one
+func chooseVersion(label string, names []string) (string, error) {
 	// If only one version exists, use it automatically
-	if len(matchingSnippets) == 1 {
-		return addSnippet(st, matchingSnippets[0], destPath)
+	if len(names) == 1 {
+		return names[0], nil
 	}
 
 	// Multiple versions - prompt user to choose
-	fmt.Printf("Multiple versions found for '%s':\n", baseName)
-	for i, name := range matchingSnippets {
+	if addVars.noInput {
+		return "", fmt.Errorf("multiple versions found for '%s' (%s); give a version, constraint or tag such as @latest",
+			label, strings.Join(names, ", "))
+	}
+	fmt.Printf("Multiple versions found for '%s':\n", label)
+	for i, name := range names {
 		fmt.Printf("  %d. %s\n", i+1, name)
 	}
 
-	choice, err := utils.Prompt(fmt.Sprintf("Enter version number (1-%d): ", len(matchingSnippets)))
+	choice, err := utils.Prompt(fmt.Sprintf("Enter version number (1-%d): ", len(names)))
 	if err != nil {
-		return fmt.Errorf("failed to read input: %w", err)
+		return "", fmt.Errorf("failed to read input: %w", err)
 	}
 
 	// Parse choice
 	var selectedIdx int
 	fmt.Sscanf(choice, "%d", &selectedIdx)
-	if selectedIdx < 1 || selectedIdx > len(matchingSnippets) {
-		return fmt.Errorf("invalid choice")
+	if selectedIdx < 1 || selectedIdx > len(names) {
+		return "", fmt.Errorf("invalid choice")
 	}
 
-	return addSnippet(st, matchingSnippets[selectedIdx-1], destPath)
+	return names[selectedIdx-1], nil
 }
 
 // findMatchingSnippets finds all snippets that match the given name (without version/extension)
@@ -183,33 +346,32 @@ func findMatchingSnippetsByNameAndExt(st *store.Store, name, ext string) []strin
 }
 
 func addSnippet(st *store.Store, name, destPath string) error {
-	snippetPath, ok := st.GetSnippet(name)
+	entry, ok := st.GetSnippet(name)
 	if !ok {
 		return fmt.Errorf(utils.ErrResourceNotFound, "snippet", name)
 	}
+	snippetPath := entry.Path
 
 	if !utils.FileExists(snippetPath) {
 		return fmt.Errorf(utils.ErrResourceNotFound, "snippet file", snippetPath)
 	}
+	if err := checkIntegrity(store.VerifySnippet(entry), addSkipVerify); err != nil {
+		return err
+	}
 
 	// Parse snippet metadata to check for variables
 	meta, err := utils.ParseSnippetMetadata(snippetPath)
 	if err != nil {
 		return fmt.Errorf("failed to parse snippet metadata: %w", err)
 	}
+	if err := meta.CheckVariables(); err != nil {
+		return fmt.Errorf("snippet '%s': %w", name, err)
+	}
 
-	// Prompt user for variable values if variables exist
-	varReplacements := make(map[string]string)
-	if len(meta.Variables) > 0 {
-		fmt.Println("Template variables found:")
-		for varName, defaultValue := range meta.Variables {
-			prompt := fmt.Sprintf("  %s", varName)
-			value, err := utils.PromptWithDefault(prompt, defaultValue)
-			if err != nil {
-				return fmt.Errorf("failed to read variable input: %w", err)
-			}
-			varReplacements[varName] = value
-		}
+	// Take given variable values and prompt for the rest
+	varReplacements, err := addVars.resolve(meta, nil, destPath)
+	if err != nil {
+		return err
 	}
 
 	// Extract base name without version: errorHandler@1.js -> errorHandler.js
@@ -228,45 +390,95 @@ func addSnippet(st *store.Store, name, destPath string) error {
 
 	fmt.Printf(utils.MsgSnippetAdded, name, destFile)
 	logger.Info(fmt.Sprintf("Snippet added: %s -> %s", name, destFile))
-	return nil
+
+	return recordAdd(lockfile.Entry{
+		Kind:      lockfile.KindSnippet,
+		Name:      entry.Name,
+		Version:   entry.Version,
+		Extension: entry.Extension,
+		Checksum:  entry.Checksum,
+		Variables: varReplacements,
+	}, destFile, []string{destFile})
 }
 
 func addStack(st *store.Store, name, destPath string) error {
-	stackPath, ok := st.GetStack(name)
+	entry, ok := st.GetStack(name)
 	if !ok {
 		return fmt.Errorf(utils.ErrResourceNotFound, "stack", name)
 	}
+	stackPath := entry.Path
 
 	if !utils.IsDirectory(stackPath) {
 		return fmt.Errorf(utils.ErrResourceNotFound, "stack directory", stackPath)
 	}
+	if err := checkIntegrity(store.VerifyStack(entry), addSkipVerify); err != nil {
+		return err
+	}
 
 	if utils.FileExists(destPath) && destPath != "." && !addForce {
 			return fmt.Errorf(utils.ErrDestAlreadyExists, destPath)
 	}
 
-	ignorePatterns := []string{"node_modules", ".git", ".DS_Store", "Thumbs.db"}
-	if err := utils.CopyDir(stackPath, destPath, ignorePatterns); err != nil {
+	if err := utils.CopyDir(stackPath, destPath, stackIgnorePatterns); err != nil {
 		return fmt.Errorf("failed to copy stack: %w", err)
 	}
 
 	fmt.Printf(utils.MsgStackAdded, name, destPath)
 	logger.Info(fmt.Sprintf("Stack added: %s -> %s", name, destPath))
+
+	copied, err := stackFiles(stackPath, stackIgnorePatterns)
+	if err != nil {
+		return err
+	}
+	files := make([]string, 0, len(copied))
+	for rel := range copied {
+		files = append(files, filepath.Join(destPath, filepath.FromSlash(rel)))
+	}
+	slices.Sort(files)
+	return recordAdd(lockfile.Entry{
+		Kind:     lockfile.KindStack,
+		Name:     entry.Name,
+		Version:  entry.Version,
+		Checksum: entry.Checksum,
+	}, destPath, files)
+}
+
+// checkIntegrity turns a failed checksum verification into an error, or
+// into a warning with force
+func checkIntegrity(err error, skip bool) error {
+	var ierr *store.IntegrityError
+	if !errors.As(err, &ierr) {
+		return err
+	}
+	if !skip {
+		return fmt.Errorf("%w; use --%s to use it anyway", err, FlagSkipVerify)
+	}
+	fmt.Printf("⚠ %v; using it anyway\n", err)
+	logger.Info(fmt.Sprintf("Integrity check overridden with --%s: %v", FlagSkipVerify, err))
 	return nil
 }
 
+// stackIgnorePatterns are never copied out of a stored stack
+var stackIgnorePatterns = []string{"node_modules", ".git", ".DS_Store", "Thumbs.db", store.StackConfigFile}
+
 var (
-	addRemote bool
-	addTo     string
-	addGlobal bool
-	addBoth   bool
-	addForce  bool
+	addRemote     bool
+	addTo         string
+	addLocal      bool
+	addGlobal     bool
+	addBoth       bool
+	addForce      bool
+	addSkipVerify bool
+	addVars       variableInput
 )
 
 func init() {
 	addCmd.Flags().BoolVarP(&addRemote, "remote", "r", false, "Fetch from remote registry")
 	addCmd.Flags().StringVarP(&addTo, "to", "t", ".", "Destination path")
-	addCmd.Flags().BoolVarP(&addGlobal, "global", "g", false, "Add to global store")
-	addCmd.Flags().BoolVarP(&addBoth, "both", "b", false, "Add to both local and global")
-	addCmd.Flags().BoolVarP(&addForce, FlagForce, FlagForceShort, false, DescForce)
+	addCmd.Flags().BoolVarP(&addLocal, FlagLocal, FlagLocalShort, false, "Only look in the project-local store")
+	addCmd.Flags().BoolVarP(&addGlobal, FlagGlobal, FlagGlobalShort, false, "Only look in the global store")
+	addCmd.Flags().BoolVarP(&addBoth, FlagBoth, FlagBothShort, false, "Look in the local store, then the global one (default)")
+	addCmd.Flags().BoolVarP(&addForce, FlagForce, FlagForceShort, false, "Overwrite existing files")
+	addCmd.Flags().BoolVar(&addSkipVerify, FlagSkipVerify, false, DescSkipVerify)
+	addVars.register(addCmd)
 }
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+func TestAddChecksIntegrity(t *testing.T) {
+	useTestConfig(t)
+	st, err := utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snippetPath := filepath.Join(cfg.Paths.Snippets, "js", "logger@1.0.0.js")
+	stackPath := filepath.Join(cfg.Paths.Stacks, "api@1.0.0")
+	writeFiles(t, cfg.Paths.Store, map[string]string{
+		"snippets/js/logger@1.0.0.js":        "// __author Jane\nconsole.log('hi')\n",
+		"stacks/api@1.0.0/app.js":            "listen()\n",
+		"stacks/api@1.0.0/boiler.stack.json": `{"id": "api", "version": "1.0.0"}`,
+	})
+	snippet, err := store.NewSnippetEntry("logger@1.0.0.js", snippetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddSnippet(snippet); err != nil {
+		t.Fatal(err)
+	}
+	stack, err := store.NewStackEntry("api@1.0.0", stackPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddStack(stack); err != nil {
+		t.Fatal(err)
+	}
+
+	// Edit both in the store by hand
+	writeFiles(t, cfg.Paths.Store, map[string]string{
+		"snippets/js/logger@1.0.0.js": "// __author Jane\nconsole.log('tampered')\n",
+		"stacks/api@1.0.0/app.js":     "tampered()\n",
+	})
+
+	var ierr *store.IntegrityError
+	err = addSnippet(st, "logger@1.0.0.js", "logger.js")
+	if !errors.As(err, &ierr) || !strings.Contains(err.Error(), "--skip-verify") {
+		t.Fatalf("addSnippet() error = %v, want an integrity error naming --skip-verify", err)
+	}
+	err = addStack(st, "api@1.0.0", "api")
+	if !errors.As(err, &ierr) || !strings.Contains(err.Error(), "--skip-verify") {
+		t.Fatalf("addStack() error = %v, want an integrity error naming --skip-verify", err)
+	}
+	for _, dest := range []string{"logger.js", "api"} {
+		if _, err := os.Stat(dest); !os.IsNotExist(err) {
+			t.Errorf("%s was written despite the failed check", dest)
+		}
+	}
+
+	// --force only overwrites files, it does not turn off the check
+	addForce = true
+	t.Cleanup(func() { addForce = false })
+	if err := addSnippet(st, "logger@1.0.0.js", "logger.js"); !errors.As(err, &ierr) {
+		t.Fatalf("addSnippet() with --force error = %v, want an integrity error", err)
+	}
+	addForce = false
+
+	addSkipVerify = true
+	t.Cleanup(func() { addSkipVerify = false })
+	if err := addSnippet(st, "logger@1.0.0.js", "logger.js"); err != nil {
+		t.Fatalf("addSnippet() with --skip-verify error = %v", err)
+	}
+	if err := addStack(st, "api@1.0.0", "api"); err != nil {
+		t.Fatalf("addStack() with --skip-verify error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join("api", "app.js")); string(data) != "tampered()\n" {
+		t.Errorf("api/app.js = %q, want the stored content", data)
+	}
+}
@@ -21,7 +21,11 @@ You can:
   - Remove all stacks (use -k or --stacks flag)
   - Clear everything (use -a or --all flag)
 
-Version-specific deletion is supported.`,
+Version-specific deletion is supported.
+
+Removed resources are moved to the trash under the Boiler root, not deleted.
+Use 'bl restore <name>' to bring them back and 'bl trash empty' to delete
+them for good.`,
 	Example: `  # Remove specific snippet
   bl clean errorHandler@1.js
 
@@ -99,8 +103,7 @@ func cleanResource(resource string) error {
 }
 
 func cleanSnippet(st *store.Store, name string) error {
-	path, ok := st.GetSnippet(name)
-	if !ok {
+	if !st.SnippetExists(name) {
 		return fmt.Errorf(utils.ErrResourceNotFound, "snippet", name)
 	}
 
@@ -109,24 +112,18 @@ func cleanSnippet(st *store.Store, name string) error {
 		return nil
 	}
 
-	if utils.FileExists(path) {
-		if err := os.Remove(path); err != nil {
-			return fmt.Errorf("failed to remove file: %w", err)
-		}
-	}
-
-	if err := st.RemoveSnippet(name); err != nil {
-		return fmt.Errorf("failed to update metadata: %w", err)
+	if err := removeResources(st, []string{name}, nil); err != nil {
+		return err
 	}
 
 	fmt.Printf(utils.MsgSnippetRemoved, name)
+	fmt.Println(utils.MsgTrashHint)
 	logger.Info(fmt.Sprintf("Snippet removed: %s", name))
 	return nil
 }
 
 func cleanStack(st *store.Store, name string) error {
-	path, ok := st.GetStack(name)
-	if !ok {
+	if !st.StackExists(name) {
 		return fmt.Errorf(utils.ErrResourceNotFound, "stack", name)
 	}
 
@@ -135,17 +132,12 @@ func cleanStack(st *store.Store, name string) error {
 		return nil
 	}
 
-	if utils.IsDirectory(path) {
-		if err := os.RemoveAll(path); err != nil {
-			return fmt.Errorf("failed to remove directory: %w", err)
-		}
-	}
-
-	if err := st.RemoveStack(name); err != nil {
-		return fmt.Errorf("failed to update metadata: %w", err)
+	if err := removeResources(st, nil, []string{name}); err != nil {
+		return err
 	}
 
 	fmt.Printf(utils.MsgStackRemoved, name)
+	fmt.Println(utils.MsgTrashHint)
 	logger.Info(fmt.Sprintf("Stack removed: %s", name))
 	return nil
 }
@@ -163,24 +155,13 @@ func cleanAllResources() error {
 	}
 
 	snippets := st.ListSnippets()
-	for _, name := range snippets {
-		path, _ := st.GetSnippet(name)
-		if utils.FileExists(path) {
-			os.Remove(path)
-		}
-		st.RemoveSnippet(name)
-	}
-
 	stacks := st.ListStacks()
-	for _, name := range stacks {
-		path, _ := st.GetStack(name)
-		if utils.IsDirectory(path) {
-			os.RemoveAll(path)
-		}
-		st.RemoveStack(name)
+	if err := removeResources(st, snippets, stacks); err != nil {
+		return err
 	}
 
-	fmt.Printf("✓ Removed %d snippets and %d stacks\n", len(snippets), len(stacks))
+	fmt.Printf("✓ Moved %d snippets and %d stacks to trash\n", len(snippets), len(stacks))
+	fmt.Println(utils.MsgTrashHint)
 	logger.Info("All resources cleaned")
 	return nil
 }
@@ -202,15 +183,12 @@ func cleanAllSnippets() error {
 		return nil
 	}
 
-	for _, name := range snippets {
-		path, _ := st.GetSnippet(name)
-		if utils.FileExists(path) {
-			os.Remove(path)
-		}
-		st.RemoveSnippet(name)
+	if err := removeResources(st, snippets, nil); err != nil {
+		return err
 	}
 
-	fmt.Printf("✓ Removed %d snippets\n", len(snippets))
+	fmt.Printf("✓ Moved %d snippets to trash\n", len(snippets))
+	fmt.Println(utils.MsgTrashHint)
 	logger.Info(fmt.Sprintf("Cleaned %d snippets", len(snippets)))
 	return nil
 }
@@ -232,19 +210,46 @@ func cleanAllStacks() error {
 		return nil
 	}
 
-	for _, name := range stacks {
-		path, _ := st.GetStack(name)
-		if utils.IsDirectory(path) {
-			os.RemoveAll(path)
-		}
-		st.RemoveStack(name)
+	if err := removeResources(st, nil, stacks); err != nil {
+		return err
 	}
 
-	fmt.Printf("✓ Removed %d stacks\n", len(stacks))
+	fmt.Printf("✓ Moved %d stacks to trash\n", len(stacks))
+	fmt.Println(utils.MsgTrashHint)
 	logger.Info(fmt.Sprintf("Cleaned %d stacks", len(stacks)))
 	return nil
 }
 
+// removeResources moves the files of the given snippets and stacks to the
+// trash and drops their meta entries as one transaction. If any step fails,
+// files already moved are put back and the meta file is left untouched.
+func removeResources(st *store.Store, snippets, stacks []string) error {
+	tx, err := st.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := st.MoveToTrash(tx, openTrash(), snippets, stacks); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	commitStore(st.Root(), cleanMessage(snippets, stacks))
+	return nil
+}
+
+// cleanMessage is the store commit message for removed resources
+func cleanMessage(snippets, stacks []string) string {
+	names := append(append([]string{}, snippets...), stacks...)
+	if len(names) > 3 {
+		return fmt.Sprintf("Clean %d snippets and %d stacks", len(snippets), len(stacks))
+	}
+	return "Clean " + strings.Join(names, ", ")
+}
+
 func interactiveClean() error {
 	fmt.Println("\nSelect action:")
 	fmt.Println("  k - clean all stacks")
@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/diff"
+	"github.com/rishiyaduwanshi/boiler/internal/models"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <resource> <resource|path>",
+	Short: "Show changes between resource versions",
+	Long: `Show a unified diff between two stored versions of a resource, or between
+a stored version and a file or folder in your project.
+
+The first argument is always a stored resource. The second argument is read
+from disk when such a file or folder exists, otherwise it is another stored
+resource, looked up in the project-local store first and then in the
+global one. Versions can be exact, constraints (^2) or tags (stable); without a
+version the latest one is used.
+
+Stacks are compared file by file. Files matched by the stack's ignore rules
+(boiler.stack.json) and common clutter like node_modules or .git are skipped.`,
+	Example: `  # Compare two snippet versions
+  bl diff logger@1.js logger@2.js
+
+  # Compare two stack versions
+  bl diff express@1 express@2
+
+  # Compare the stored snippet with the copy in your project
+  bl diff logger.js ./src/logger.js
+
+  # Compare a stack with a project folder
+  bl diff express@stable ./my-api`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger.Info(fmt.Sprintf("Diffing %s and %s", args[0], args[1]))
+
+		if err := runDiff(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// diffSide is one side of a comparison: a label and a path on disk
+type diffSide struct {
+	label   string
+	path    string
+	isStack bool
+}
+
+func runDiff(from, to string) error {
+	a, err := storedSide(from)
+	if err != nil {
+		return err
+	}
+
+	var b diffSide
+	if utils.FileExists(to) {
+		b = diffSide{label: filepath.ToSlash(to), path: to, isStack: utils.IsDirectory(to)}
+	} else if b, err = storedSide(to); err != nil {
+		return err
+	}
+
+	if a.isStack != b.isStack {
+		return fmt.Errorf("cannot compare a snippet with a stack ('%s' and '%s')", a.label, b.label)
+	}
+
+	var out string
+	if a.isStack {
+		out, err = diffStacks(a, b)
+	} else {
+		out, err = diffFiles(a.path, b.path, a.label, b.label)
+	}
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		fmt.Printf("✓ No differences between '%s' and '%s'\n", a.label, b.label)
+		return nil
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// storedSide looks a resource up in the local store, then the global one
+func storedSide(resource string) (diffSide, error) {
+	baseName, spec, ext := store.ParseResourceName(resource)
+
+	scopes, err := readScopes(false, false)
+	if err != nil {
+		return diffSide{}, err
+	}
+	st, _, err := lookupStore(scopes, baseName, ext)
+	if err != nil {
+		return diffSide{}, err
+	}
+
+	fullName, isStack, err := resolveResource(st, baseName, spec, ext)
+	if err != nil {
+		return diffSide{}, err
+	}
+
+	if isStack {
+		entry, _ := st.GetStack(fullName)
+		return diffSide{label: fullName, path: entry.Path, isStack: true}, nil
+	}
+	entry, _ := st.GetSnippet(fullName)
+	return diffSide{label: fullName, path: entry.Path}, nil
+}
+
+// diffFiles diffs two files. An empty path stands for a missing file.
+func diffFiles(fromPath, toPath, fromLabel, toLabel string) (string, error) {
+	a, err := readDiffFile(fromPath)
+	if err != nil {
+		return "", err
+	}
+	b, err := readDiffFile(toPath)
+	if err != nil {
+		return "", err
+	}
+
+	if fromPath == "" {
+		fromLabel = "/dev/null"
+	}
+	if toPath == "" {
+		toLabel = "/dev/null"
+	}
+	return diffBytes(a, b, fromLabel, toLabel), nil
+}
+
+// diffBytes diffs two file contents, or reports that binary ones differ
+func diffBytes(a, b []byte, fromLabel, toLabel string) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	if isBinary(a) || isBinary(b) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", fromLabel, toLabel)
+	}
+	return diff.Unified(fromLabel, toLabel, diff.SplitLines(string(a)), diff.SplitLines(string(b)), diff.DefaultContext)
+}
+
+func readDiffFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// diffStacks compares two stack folders file by file
+func diffStacks(a, b diffSide) (string, error) {
+	ignore := append(stackIgnore(a.path), stackIgnore(b.path)...)
+
+	aFiles, err := stackFiles(a.path, ignore)
+	if err != nil {
+		return "", err
+	}
+	bFiles, err := stackFiles(b.path, ignore)
+	if err != nil {
+		return "", err
+	}
+
+	names := make(map[string]bool)
+	for name := range aFiles {
+		names[name] = true
+	}
+	for name := range bFiles {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var sb strings.Builder
+	for _, name := range sorted {
+		out, err := diffFiles(aFiles[name], bFiles[name], a.label+"/"+name, b.label+"/"+name)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(out)
+	}
+	return sb.String(), nil
+}
+
+// stackIgnore returns the ignore rules of the stack config in dir, if any,
+// on top of the patterns never copied out of a stack
+func stackIgnore(dir string) []string {
+	patterns := append([]string{}, stackIgnorePatterns...)
+	if config, err := models.ParseStackConfig(dir); err == nil {
+		patterns = append(patterns, models.ResolveIgnorePatterns(config)...)
+	}
+	return patterns
+}
+
+// stackFiles maps slash-separated relative paths to files in dir, skipping
+// any file or folder whose name is ignored
+func stackFiles(dir string, ignore []string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if utils.ShouldIgnore(d.Name(), ignore) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = path
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return files, nil
+}
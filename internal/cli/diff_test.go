@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffStacks(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"v1/app.js":            "listen(3000)\n",
+		"v1/old.js":            "old()\n",
+		"v1/node_modules/x.js": "ignored\n",
+		"v1/boiler.stack.json": `{"id": "api", "version": "1", "ignore": ["debug.log"]}`,
+		"v2/app.js":            "listen(8080)\n",
+		"v2/new.js":            "new()\n",
+		"v2/debug.log":         "ignored\n",
+		"v2/node_modules/x.js": "also ignored\n",
+		"v2/boiler.stack.json": `{"id": "api", "version": "2", "ignore": ["debug.log"]}`,
+	} {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := diffSide{label: "api@1", path: filepath.Join(root, "v1"), isStack: true}
+	b := diffSide{label: "api@2", path: filepath.Join(root, "v2"), isStack: true}
+	got, err := diffStacks(a, b)
+	if err != nil {
+		t.Fatalf("diffStacks() error = %v", err)
+	}
+
+	want := `--- api@1/app.js
++++ api@2/app.js
+@@ -1 +1 @@
+-listen(3000)
++listen(8080)
+--- /dev/null
++++ api@2/new.js
+@@ -0,0 +1 @@
++new()
+--- api@1/old.js
++++ /dev/null
+@@ -1 +0,0 @@
+-old()
+`
+	if got != want {
+		t.Errorf("diffStacks() =\n%s\nwant\n%s", got, want)
+	}
+	for _, ignored := range []string{"node_modules", "debug.log", "boiler.stack.json"} {
+		if strings.Contains(got, ignored) {
+			t.Errorf("diffStacks() shows ignored %s", ignored)
+		}
+	}
+}
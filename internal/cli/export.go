@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rishiyaduwanshi/boiler/internal/bundle"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [resource...]",
+	Short: "Export snippets and stacks to a .blpack file",
+	Long: `Pack snippets and stacks into a single .blpack file to hand to someone else.
+
+A resource without a version exports every version of it; with a version,
+constraint or tag only the matching one. A .blpack is a tar.gz with a
+manifest listing every resource with its metadata, tags and the SHA-256 of
+each file. Import it with 'bl import'.
+
+With a key from 'bl key gen', the bundle is signed.`,
+	Example: `  # Export every version of a snippet
+  bl export logger.js
+
+  # Export a stack version and a snippet into one file
+  bl export express@1.0.0 logger@stable.js -o team.blpack
+
+  # Export the whole global store
+  bl export --all`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !exportAll && len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: name resources to export, or use --all")
+			os.Exit(1)
+		}
+
+		if err := exportResources(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func exportResources(args []string) error {
+	var resources []bundle.Resource
+	var err error
+	if exportAll {
+		resources, err = exportAllResources()
+	} else {
+		resources, err = exportNamed(args)
+	}
+	if err != nil {
+		return err
+	}
+
+	out := exportOutput
+	if out == "" {
+		out = "boiler" + bundle.Ext
+		if len(args) == 1 {
+			name, _, _ := store.ParseResourceName(args[0])
+			out = name + bundle.Ext
+		}
+	}
+
+	key, err := signingKey()
+	if err != nil {
+		return err
+	}
+	if err := bundle.Write(out, resources, key); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Exported %d resource(s) to %s\n", len(resources), out)
+	if key == nil {
+		fmt.Println("  Not signed; run 'bl key gen' to sign exports")
+	}
+	for _, r := range resources {
+		fmt.Printf("  • %s\n", r.FullName())
+	}
+	logger.Info(fmt.Sprintf("Exported %d resource(s) to %s", len(resources), out))
+	return nil
+}
+
+// exportAllResources returns everything in the global store, or the local
+// one with --local
+func exportAllResources() ([]bundle.Resource, error) {
+	scopes, err := readScopes(exportLocal, !exportLocal)
+	if err != nil {
+		return nil, err
+	}
+	st, err := scopes[0].load()
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []bundle.Resource
+	for _, name := range st.ListSnippets() {
+		entry, _ := st.GetSnippet(name)
+		resources = append(resources, snippetBundleResource(st, entry))
+	}
+	for _, name := range st.ListStacks() {
+		entry, _ := st.GetStack(name)
+		resources = append(resources, stackBundleResource(st, entry))
+	}
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("the %s store is empty", scopes[0].Name)
+	}
+	return resources, nil
+}
+
+// exportNamed resolves each argument in the local then global store
+func exportNamed(args []string) ([]bundle.Resource, error) {
+	scopes, err := readScopes(exportLocal, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []bundle.Resource
+	seen := make(map[string]bool)
+	add := func(r bundle.Resource) {
+		if !seen[r.Kind+":"+r.FullName()] {
+			seen[r.Kind+":"+r.FullName()] = true
+			resources = append(resources, r)
+		}
+	}
+
+	for _, arg := range args {
+		baseName, spec, ext := store.ParseResourceName(arg)
+		st, _, err := lookupStore(scopes, baseName, ext)
+		if err != nil {
+			return nil, err
+		}
+
+		if spec != "" {
+			fullName, isStack, err := resolveResource(st, baseName, spec, ext)
+			if err != nil {
+				return nil, err
+			}
+			if isStack {
+				entry, _ := st.GetStack(fullName)
+				add(stackBundleResource(st, entry))
+			} else {
+				entry, _ := st.GetSnippet(fullName)
+				add(snippetBundleResource(st, entry))
+			}
+			continue
+		}
+
+		// Every version of every matching snippet and stack
+		found := false
+		for _, name := range st.ListSnippets() {
+			entry, _ := st.GetSnippet(name)
+			if entry.Name == baseName && (ext == "" || entry.Extension == ext) {
+				add(snippetBundleResource(st, entry))
+				found = true
+			}
+		}
+		if ext == "" {
+			for _, name := range st.ListStacks() {
+				entry, _ := st.GetStack(name)
+				if entry.Name == baseName {
+					add(stackBundleResource(st, entry))
+					found = true
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("'%s' not found", arg)
+		}
+	}
+	return resources, nil
+}
+
+func snippetBundleResource(st *store.Store, e *store.SnippetEntry) bundle.Resource {
+	return bundle.Resource{
+		Kind:        bundle.KindSnippet,
+		Name:        e.Name,
+		Version:     e.Version,
+		Extension:   e.Extension,
+		Author:      e.Author,
+		Description: e.Description,
+		Message:     e.Message,
+		Tags:        versionTags(st.Tags(store.ResourceKey(e.Name, e.Extension)))[e.Version],
+		CreatedAt:   e.CreatedAt,
+		Src:         e.Path,
+	}
+}
+
+func stackBundleResource(st *store.Store, e *store.StackEntry) bundle.Resource {
+	return bundle.Resource{
+		Kind:        bundle.KindStack,
+		Name:        e.Name,
+		Version:     e.Version,
+		Author:      e.Author,
+		Description: e.Description,
+		Message:     e.Message,
+		Tags:        versionTags(st.Tags(store.ResourceKey(e.Name, "")))[e.Version],
+		CreatedAt:   e.CreatedAt,
+		Src:         e.Path,
+	}
+}
+
+var (
+	exportAll    bool
+	exportOutput string
+	exportLocal  bool
+)
+
+func init() {
+	exportCmd.Flags().BoolVarP(&exportAll, FlagAll, FlagAllShort, false, "Export every snippet and stack in the store")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write (default: <name>.blpack or boiler.blpack)")
+	exportCmd.Flags().BoolVarP(&exportLocal, FlagLocal, FlagLocalShort, false, "Export from the project-local store only")
+}
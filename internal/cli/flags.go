@@ -7,16 +7,26 @@ const (
 	FlagStacksShort   = "k" // -k for stacks
 	FlagForceShort    = "f" // -f for force operations
 	FlagAllShort      = "a" // -a for all resources
+	FlagLocalShort    = "l" // -l for the project-local store
+	FlagGlobalShort   = "g" // -g for the global store
+	FlagBothShort     = "b" // -b for both stores
 
 	// Long flags
-	FlagSnippets = "snippets"
-	FlagStacks   = "stacks"
-	FlagForce    = "force"
-	FlagAll      = "all"
+	FlagSnippets   = "snippets"
+	FlagStacks     = "stacks"
+	FlagForce      = "force"
+	FlagAll        = "all"
+	FlagLocal      = "local"
+	FlagGlobal     = "global"
+	FlagBoth       = "both"
+	FlagSkipVerify = "skip-verify"
 
 	// Flag descriptions
 	DescSnippetsOnly = "Snippets only"
 	DescStacksOnly   = "Stacks only"
 	DescForce        = "Force operation without confirmation"
 	DescCleanAll     = "Clean all resources"
+	DescLocal        = "Use the project-local .boiler/ store"
+	DescGlobal       = "Use the global store"
+	DescSkipVerify   = "Use resources that fail the checksum check"
 )
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rishiyaduwanshi/boiler/internal/models"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Check the store for integrity problems",
+	Long: `Check that boiler.meta.json and the files in your stores agree.
+
+The project-local store, if there is one, and the global store are checked.
+Use --local or --global to check only one of them.
+
+Reports:
+  - Index entries whose file or directory no longer exists
+  - Snippet files and stack directories that are not in the index
+  - Stored stacks without a boiler.stack.json
+
+Stacks stored by older versions of bl never had a boiler.stack.json. They
+are listed as legacy but are not counted as problems.
+
+With --fix the problems are repaired:
+  - Entries with missing paths are removed from the index
+  - Unindexed files are added using their metadata or stack config
+  - Missing stack configs, legacy ones included, are recreated from the
+    index entry
+
+The command exits with an error if problems are found and not fixed.`,
+	Example: `  # Check the local and global stores
+  bl fsck
+
+  # Check only the global store
+  bl fsck --global
+
+  # Check and repair
+  bl fsck --fix`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger.Info("Checking store integrity")
+
+		if err := checkStore(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func checkStore() error {
+	scopes, err := readScopes(fsckLocal, fsckGlobal)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, sc := range scopes {
+		if len(scopes) > 1 {
+			fmt.Printf("%s store (%s):\n", sc.Name, sc.Root)
+		}
+		n, err := checkScope(sc)
+		if err != nil {
+			return err
+		}
+		failed += n
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("found %d problem(s). Run 'bl fsck --fix' to repair", failed)
+	}
+	return nil
+}
+
+// checkScope checks one store, repairing it with --fix, and returns how
+// many problems are left that make fsck fail. Legacy stacks are reported
+// but never counted.
+func checkScope(sc storeScope) (int, error) {
+	st, err := sc.load()
+	if err != nil {
+		return 0, err
+	}
+
+	problems, err := st.Check(sc.Snippets, sc.Stacks)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("✓ Store is consistent")
+		return 0, nil
+	}
+
+	legacy := 0
+	for _, p := range problems {
+		if p.Kind == store.ProblemLegacyConfig {
+			legacy++
+			fmt.Printf("  • %s\n", p)
+			continue
+		}
+		fmt.Printf("  ✗ %s\n", p)
+	}
+
+	if !fsckFix {
+		if legacy > 0 {
+			fmt.Printf("  • %d stack(s) were stored before stacks kept their config. Run 'bl fsck --fix' to add one\n", legacy)
+		}
+		return len(problems) - legacy, nil
+	}
+
+	fixed, err := repairStore(st, problems)
+	fmt.Printf("✓ Fixed %d of %d problem(s)\n", fixed, len(problems))
+	logger.Info(fmt.Sprintf("Store %s repaired: %d of %d problems fixed", sc.Root, fixed, len(problems)))
+	return 0, err
+}
+
+// repairStore fixes the given problems and returns how many were fixed.
+// Missing entries are dropped before orphans are indexed, so an orphan can
+// take over the name of an entry whose file went missing.
+func repairStore(st *store.Store, problems []store.Problem) (int, error) {
+	fixed := 0
+
+	var snippets, stacks []string
+	for _, p := range problems {
+		if p.Kind != store.ProblemMissingPath {
+			continue
+		}
+		if p.Type == "snippet" {
+			snippets = append(snippets, p.Name)
+		} else {
+			stacks = append(stacks, p.Name)
+		}
+	}
+	if len(snippets) > 0 || len(stacks) > 0 {
+		if err := st.RemoveEntries(snippets, stacks); err != nil {
+			return fixed, fmt.Errorf("failed to update metadata: %w", err)
+		}
+		fixed += len(snippets) + len(stacks)
+	}
+
+	for _, p := range problems {
+		var err error
+		switch {
+		case p.Kind == store.ProblemOrphan && p.Type == "snippet":
+			err = adoptSnippet(st, p.Path)
+		case p.Kind == store.ProblemOrphan && p.Type == "stack":
+			err = adoptStack(st, p.Path)
+		case p.Kind == store.ProblemMissingConfig || p.Kind == store.ProblemLegacyConfig:
+			err = restoreStackConfig(st, p.Name)
+		default:
+			continue
+		}
+
+		if err != nil {
+			fmt.Printf("  ⚠ Could not fix %s: %v\n", p.Path, err)
+			continue
+		}
+		fixed++
+	}
+
+	return fixed, nil
+}
+
+// adoptSnippet adds an unindexed snippet file to the index
+func adoptSnippet(st *store.Store, path string) error {
+	entry, err := indexSnippetFile(path)
+	if err != nil {
+		return err
+	}
+	if st.SnippetExists(entry.FullName()) {
+		return fmt.Errorf("snippet '%s' is already indexed from another path", entry.FullName())
+	}
+	return st.AddSnippet(entry)
+}
+
+// adoptStack adds an unindexed stack directory to the index
+func adoptStack(st *store.Store, path string) error {
+	entry, err := indexStackDir(path)
+	if err != nil {
+		return err
+	}
+	if st.StackExists(entry.FullName()) {
+		return fmt.Errorf("stack '%s' is already indexed from another path", entry.FullName())
+	}
+	return st.AddStack(entry)
+}
+
+// restoreStackConfig recreates boiler.stack.json from the stack's index
+// entry and records its digest, so the repaired stack verifies again
+func restoreStackConfig(st *store.Store, name string) error {
+	return st.Update(func() error {
+		entry, ok := st.GetStack(name)
+		if !ok {
+			return fmt.Errorf(utils.ErrResourceNotFound, "stack", name)
+		}
+
+		config := &models.StackConfig{
+			ID:          entry.Name,
+			Version:     entry.Version,
+			Author:      entry.Author,
+			Description: entry.Description,
+			CreatedAt:   entry.CreatedAt,
+			Ignore:      []string{},
+		}
+		if err := models.SaveStackConfig(entry.Path, config); err != nil {
+			return err
+		}
+
+		files, err := store.FileDigests(entry.Path)
+		if err != nil {
+			return err
+		}
+		// Only the new config is taken from disk; other files keep the
+		// digests they were stored with
+		switch {
+		case len(entry.Files) > 0:
+			entry.Files[store.StackConfigFile] = files[store.StackConfigFile]
+			entry.Checksum = store.DigestRoot(entry.Files)
+		case entry.Checksum != "":
+			entry.Checksum = store.DigestRoot(files)
+		}
+		return nil
+	})
+}
+
+var (
+	fsckFix    bool
+	fsckLocal  bool
+	fsckGlobal bool
+)
+
+func init() {
+	fsckCmd.Flags().BoolVar(&fsckFix, "fix", false, "Repair the problems that were found")
+	fsckCmd.Flags().BoolVarP(&fsckLocal, FlagLocal, FlagLocalShort, false, "Only check the project-local store")
+	fsckCmd.Flags().BoolVarP(&fsckGlobal, FlagGlobal, FlagGlobalShort, false, "Only check the global store")
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/models"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+func TestRepairStore(t *testing.T) {
+	root := t.TempDir()
+	snippetsDir := filepath.Join(root, "snippets")
+	stacksDir := filepath.Join(root, "stacks")
+	for path, content := range map[string]string{
+		filepath.Join(snippetsDir, "js", "orphan@2.js"):        "// __author Jane\n// __desc Found on disk\nx()\n",
+		filepath.Join(stacksDir, "api@1", "main.go"):           "package main\n",
+		filepath.Join(stacksDir, "web@3", "index.html"):        "<p>hi</p>\n",
+		filepath.Join(stacksDir, "web@3", "boiler.stack.json"): `{"id": "site", "version": "3", "author": "Ann"}`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := store.NewStore(root)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	gone := &store.SnippetEntry{Name: "gone", Version: "1", Extension: ".js", Path: filepath.Join(snippetsDir, "js", "gone@1.js")}
+	if err := st.AddSnippet(gone); err != nil {
+		t.Fatal(err)
+	}
+	api := &store.StackEntry{Name: "api", Version: "1", Author: "Bob", Path: filepath.Join(stacksDir, "api@1")}
+	if err := st.AddStack(api); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := st.Check(snippetsDir, stacksDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := repairStore(st, problems)
+	if err != nil {
+		t.Fatalf("repairStore() error = %v", err)
+	}
+	if fixed != len(problems) || fixed != 4 {
+		t.Fatalf("repairStore() fixed %d of %d problems, want 4 of 4", fixed, len(problems))
+	}
+
+	if problems, err := st.Check(snippetsDir, stacksDir); err != nil || len(problems) != 0 {
+		t.Fatalf("Check() after repair = %v, %v; want no problems", problems, err)
+	}
+	if st.SnippetExists("gone@1.js") {
+		t.Error("entry with a missing file is still indexed")
+	}
+	orphan, ok := st.GetSnippet("orphan@2.js")
+	if !ok || orphan.Author != "Jane" || orphan.Description != "Found on disk" {
+		t.Errorf("adopted snippet = %+v, want author and description from its metadata", orphan)
+	}
+	if site, ok := st.GetStack("site@3"); !ok || site.Author != "Ann" {
+		t.Errorf("adopted stack = %+v, want site@3 from its config", site)
+	}
+	config, err := models.ParseStackConfig(api.Path)
+	if err != nil {
+		t.Fatalf("restored config: %v", err)
+	}
+	if config.ID != "api" || config.Version != "1" || config.Author != "Bob" {
+		t.Errorf("restored config = %+v, want it built from the index entry", config)
+	}
+}
+
+func TestRestoreStackConfigKeepsStackVerified(t *testing.T) {
+	root := t.TempDir()
+	stackPath := filepath.Join(root, "stacks", "api@1.0.0")
+	if err := os.MkdirAll(stackPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(stackPath, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A stack stored without its config, as fsck reports it
+	st := store.NewStore(root)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := store.NewStackEntry("api@1.0.0", stackPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddStack(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := restoreStackConfig(st, "api@1.0.0"); err != nil {
+		t.Fatalf("restoreStackConfig() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(stackPath, store.StackConfigFile)); err != nil {
+		t.Fatalf("config was not written: %v", err)
+	}
+
+	// Read the index back from disk as the next bl command would
+	st = store.NewStore(root)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := st.GetStack("api@1.0.0")
+	if !ok {
+		t.Fatal("stack is missing after restoring its config")
+	}
+	if err := store.VerifyStack(got); err != nil {
+		t.Fatalf("VerifyStack() error = %v", err)
+	}
+	if _, ok := got.Files["main.go"]; !ok {
+		t.Error("main.go digest was dropped")
+	}
+}
+
+func TestCheckStoreScopes(t *testing.T) {
+	project := useTestConfig(t)
+	root, err := store.InitLocal(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fsckFix, fsckLocal, fsckGlobal = false, false, false })
+
+	// A stack stored before stacks kept their config
+	writeFiles(t, root, map[string]string{"stacks/api@1.0.0/main.go": "package main\n"})
+	stackPath := filepath.Join(root, "stacks", "api@1.0.0")
+	local, err := utils.LoadStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := store.NewStackEntry("api@1.0.0", stackPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := local.AddStack(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkStore(); err != nil {
+		t.Fatalf("checkStore() with a legacy stack error = %v, want nil", err)
+	}
+
+	// A snippet in the global store whose file is gone
+	global, err := utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gone := &store.SnippetEntry{Name: "gone", Version: "1.0.0", Extension: ".js", Path: filepath.Join(cfg.Paths.Snippets, "js", "gone@1.0.0.js")}
+	if err := global.AddSnippet(gone); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkStore(); err == nil {
+		t.Fatal("checkStore() = nil, want the global store's problem reported")
+	}
+	fsckLocal = true
+	if err := checkStore(); err != nil {
+		t.Fatalf("checkStore() --local error = %v, want nil", err)
+	}
+
+	fsckLocal, fsckFix = false, true
+	if err := checkStore(); err != nil {
+		t.Fatalf("checkStore() --fix error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(stackPath, store.StackConfigFile)); err != nil {
+		t.Errorf("legacy stack was not migrated: %v", err)
+	}
+	local, err = utils.LoadStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := local.Check(filepath.Join(root, "snippets"), filepath.Join(root, "stacks")); err != nil || len(problems) != 0 {
+		t.Errorf("local Check() after fix = %v, %v; want no problems", problems, err)
+	}
+	global, err = utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if global.SnippetExists("gone@1.0.0.js") {
+		t.Error("global entry with a missing file is still indexed")
+	}
+}
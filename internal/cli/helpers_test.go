@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/config"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+// useTestConfig points the package config and logger at a Boiler root in a
+// temp directory and runs the test from an empty project directory next to
+// it, which it returns
+func useTestConfig(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	c := config.DefaultConfig()
+	c.Paths = config.Paths{
+		Root:     filepath.Join(root, ".boiler"),
+		Store:    filepath.Join(root, ".boiler", "store"),
+		Snippets: filepath.Join(root, ".boiler", "store", "snippets"),
+		Stacks:   filepath.Join(root, ".boiler", "store", "stacks"),
+		Logs:     filepath.Join(root, ".boiler", "logs"),
+		Bin:      filepath.Join(root, ".boiler", "bin"),
+	}
+	// Tests never talk to a registry
+	c.Registry = ""
+	log, err := utils.NewLogger(c.Paths.Logs, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldCfg, oldLogger := cfg, logger
+	cfg, logger = c, log
+	t.Cleanup(func() { cfg, logger = oldCfg, oldLogger })
+
+	project := filepath.Join(root, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+	return project
+}
+
+// writeFiles creates files below root from slash-separated relative paths
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// captureStderr runs fn and returns what it wrote to os.Stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	return captureFile(t, &os.Stderr, fn)
+}
+
+// captureStdout runs fn and returns what it wrote to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	return captureFile(t, &os.Stdout, fn)
+}
+
+func captureFile(t *testing.T, f **os.File, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := *f
+	*f = w
+	defer func() { *f = old }()
+
+	out := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- data
+	}()
+	fn()
+	w.Close()
+	return string(<-out)
+}
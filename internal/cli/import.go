@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/bundle"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// Answers to an import conflict, as in 'bl store'
+const (
+	conflictAsk       = "ask"
+	conflictOverwrite = "overwrite"
+	conflictNew       = "new"
+	conflictSkip      = "skip"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file.blpack>",
+	Short: "Import snippets and stacks from a .blpack file",
+	Long: `Merge a .blpack file made with 'bl export' into the store.
+
+Every file is checked against the SHA-256 in the bundle's manifest before
+anything is stored, and the bundle's signature against your trusted keys
+(see 'bl key'). Versions you already have with the same content are
+skipped. When a version exists with different content you choose, as with
+'bl store':
+
+  overwrite  replace the stored version
+  new        store the snippet as the next patch version
+  skip       keep the stored version
+
+Stacks take their version from boiler.stack.json, so they can only be
+overwritten or skipped. Use --on-conflict to answer for every conflict.
+Tags from the bundle are applied unless the tag is already set.`,
+	Example: `  # Import, asking on conflicts
+  bl import team.blpack
+
+  # Import into the project-local store, never replacing anything
+  bl import team.blpack --local --on-conflict skip`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger.Info(fmt.Sprintf("Importing: %s", args[0]))
+
+		if err := importBundle(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func importBundle(path string) error {
+	switch importConflict {
+	case conflictAsk, conflictOverwrite, conflictNew, conflictSkip:
+	default:
+		return fmt.Errorf("invalid --on-conflict '%s'. Use ask, overwrite, new or skip", importConflict)
+	}
+
+	b, err := bundle.Open(path)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	signer, verr := b.Verify(trustedKeys())
+	if err := checkSigned(fmt.Sprintf("Bundle '%s'", filepath.Base(path)), signer, verr); err != nil {
+		return err
+	}
+
+	scopes, err := writeScopes(importLocal, importBoth)
+	if err != nil {
+		return err
+	}
+
+	for _, sc := range scopes {
+		st, err := sc.load()
+		if err != nil {
+			return fmt.Errorf("failed to load %s store: %w", sc.Name, err)
+		}
+
+		imported, skipped := 0, 0
+		for _, r := range b.Manifest.Resources {
+			var ok bool
+			if r.Kind == bundle.KindStack {
+				ok, err = importStack(st, sc, b, r)
+			} else {
+				ok, err = importSnippet(st, sc, b, r)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import '%s': %w", r.FullName(), err)
+			}
+			if ok {
+				imported++
+			} else {
+				skipped++
+			}
+		}
+
+		fmt.Printf("✓ Imported %d resource(s) into the %s store, skipped %d\n", imported, sc.Name, skipped)
+		logger.Info(fmt.Sprintf("Imported %s into %s: %d imported, %d skipped", path, sc.Root, imported, skipped))
+		if imported > 0 {
+			commitStore(sc.Root, "Import "+filepath.Base(path))
+		}
+	}
+	return nil
+}
+
+// importSnippet stores one snippet from the bundle and reports whether it
+// was stored
+func importSnippet(st *store.Store, sc storeScope, b *bundle.Bundle, r bundle.Resource) (bool, error) {
+	src := b.Path(r)
+	version, err := store.NormalizeVersion(r.Version)
+	if err != nil {
+		return false, err
+	}
+
+	if existing, ok := st.GetSnippet(r.FullName()); ok {
+		// Overwriting keeps the name the store has for the version
+		version = existing.Version
+		sum, err := store.FileChecksum(src)
+		if err != nil {
+			return false, err
+		}
+		if existing.Checksum == sum {
+			fmt.Printf("= '%s' is already in the store\n", r.FullName())
+			return false, nil
+		}
+
+		next, err := st.GetNextVersion(r.Name, r.Extension, store.BumpPatch)
+		if err != nil {
+			return false, err
+		}
+		choice, err := importChoice(fmt.Sprintf(
+			"Snippet '%s' already exists with different content. Options:\n  (o) Overwrite it\n  (n) Import as new version (%s)\n  (s) Skip\nChoice: ",
+			r.FullName(), next), true)
+		if err != nil {
+			return false, err
+		}
+		switch choice {
+		case conflictSkip:
+			fmt.Printf("- Skipped '%s'\n", r.FullName())
+			return false, nil
+		case conflictNew:
+			version = next
+		}
+	}
+
+	fullName := store.FormatResourceName(r.Name, version, r.Extension)
+	destPath := filepath.Join(sc.Snippets, strings.TrimPrefix(r.Extension, "."), fullName)
+
+	tx, err := st.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	staged := tx.StagePath(fullName)
+	if err := utils.CopyFile(src, staged); err != nil {
+		return false, fmt.Errorf("failed to copy snippet: %w", err)
+	}
+	if err := tx.Put(staged, destPath); err != nil {
+		return false, fmt.Errorf("failed to store snippet: %w", err)
+	}
+
+	entry, err := store.NewSnippetEntry(fullName, destPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read stored snippet: %w", err)
+	}
+	entry.Author = r.Author
+	entry.Description = r.Description
+	entry.Message = r.Message
+	if !r.CreatedAt.IsZero() {
+		entry.CreatedAt = r.CreatedAt
+	}
+	if err := st.AddSnippet(entry); err != nil {
+		return false, fmt.Errorf("failed to update metadata: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	importTags(st, r, version)
+	fmt.Printf("✓ Imported snippet '%s'\n", fullName)
+	return true, nil
+}
+
+// importStack stores one stack from the bundle and reports whether it was
+// stored
+func importStack(st *store.Store, sc storeScope, b *bundle.Bundle, r bundle.Resource) (bool, error) {
+	src := b.Path(r)
+	version, err := store.NormalizeVersion(r.Version)
+	if err != nil {
+		return false, err
+	}
+	fullName := store.FormatResourceName(r.Name, version, "")
+
+	if existing, ok := st.GetStack(fullName); ok {
+		// Overwriting keeps the name the store has for the version
+		fullName, version = existing.FullName(), existing.Version
+		sum, err := store.DirChecksum(src)
+		if err != nil {
+			return false, err
+		}
+		if existing.Checksum == sum {
+			fmt.Printf("= '%s' is already in the store\n", fullName)
+			return false, nil
+		}
+
+		choice, err := importChoice(fmt.Sprintf(
+			"Stack '%s' already exists with different content. Options:\n  (o) Overwrite it\n  (s) Skip\nChoice: ",
+			fullName), false)
+		if err != nil {
+			return false, err
+		}
+		if choice == conflictNew {
+			fmt.Printf("⚠ Stacks keep the version from boiler.stack.json; skipped '%s'\n", fullName)
+			return false, nil
+		}
+		if choice == conflictSkip {
+			fmt.Printf("- Skipped '%s'\n", fullName)
+			return false, nil
+		}
+	}
+
+	stackDir := filepath.Join(sc.Stacks, fullName)
+
+	tx, err := st.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	staged := tx.StagePath(fullName)
+	if err := utils.CopyDir(src, staged, nil); err != nil {
+		return false, fmt.Errorf("failed to copy stack: %w", err)
+	}
+	if err := tx.Put(staged, stackDir); err != nil {
+		return false, fmt.Errorf("failed to store stack: %w", err)
+	}
+
+	entry, err := store.NewStackEntry(fullName, stackDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to read stored stack: %w", err)
+	}
+	entry.Author = r.Author
+	entry.Description = r.Description
+	entry.Message = r.Message
+	if !r.CreatedAt.IsZero() {
+		entry.CreatedAt = r.CreatedAt
+	}
+	if err := st.AddStack(entry); err != nil {
+		return false, fmt.Errorf("failed to update metadata: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	importTags(st, r, version)
+	fmt.Printf("✓ Imported stack '%s'\n", fullName)
+	return true, nil
+}
+
+// importChoice returns the --on-conflict answer, or asks for one
+func importChoice(prompt string, allowNew bool) (string, error) {
+	if importConflict != conflictAsk {
+		return importConflict, nil
+	}
+
+	choice, err := utils.Prompt(prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(choice)) {
+	case "o", "overwrite":
+		return conflictOverwrite, nil
+	case "n", "new":
+		if allowNew {
+			return conflictNew, nil
+		}
+	case "s", "skip":
+		return conflictSkip, nil
+	}
+	return "", fmt.Errorf("invalid choice '%s'", choice)
+}
+
+// importTags points the resource's tags at version, leaving tags that are
+// already set alone
+func importTags(st *store.Store, r bundle.Resource, version string) {
+	key := r.Key()
+	existing := st.Tags(key)
+	for _, tag := range r.Tags {
+		if _, taken := existing[tag]; taken {
+			continue
+		}
+		if err := st.SetTag(key, tag, version); err != nil {
+			fmt.Printf("⚠ Could not set tag '%s' on '%s': %v\n", tag, key, err)
+		}
+	}
+}
+
+var (
+	importConflict string
+	importLocal    bool
+	importBoth     bool
+)
+
+func init() {
+	importCmd.Flags().StringVar(&importConflict, "on-conflict", conflictAsk, "What to do when a version exists with other content: ask, overwrite, new or skip")
+	importCmd.Flags().BoolVarP(&importLocal, FlagLocal, FlagLocalShort, false, "Import into the project-local store (created if missing)")
+	importCmd.Flags().BoolVarP(&importBoth, FlagBoth, FlagBothShort, false, "Import into both the local and the global store")
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/rishiyaduwanshi/boiler/internal/store"
 	"github.com/rishiyaduwanshi/boiler/internal/utils"
@@ -17,17 +21,27 @@ var infoCmd = &cobra.Command{
 
 Shows:
   - Full path in store
+  - Version, author and description
   - File size (for snippets)
   - File count and total size (for stacks)
-  - Last modified time
-  - Version information`,
+  - Created, updated and last modified time
+  - Checksum recorded when the resource was stored, the SHA-256 of each
+    file of a stack, and whether the stored files still match them
+  - The note recorded for the version
+
+Without a version, the full version history is listed instead: every version
+with its date, author, tags and note, newest first. Versions can also be
+constraints (^2) or tags (stable).`,
 	Example: `  # Show snippet info
   bl info errorHandler@1.js
 
   # Show stack info
   bl info express-api@1
 
-  # Without version (shows all versions)
+  # Show the tagged version
+  bl info logger@stable.js
+
+  # Without version (shows the version history)
   bl info logger`,
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -42,24 +56,124 @@ Shows:
 }
 
 func showInfo(resource string) error {
-	st, err := utils.LoadStore(cfg.Paths.Store)
+	baseName, spec, ext := store.ParseResourceName(resource)
+
+	scopes, err := readScopes(false, false)
+	if err != nil {
+		return err
+	}
+	st, _, err := lookupStore(scopes, baseName, ext)
+	if err != nil {
+		return err
+	}
+	if spec == "" {
+		return showHistory(st, baseName, ext)
+	}
+
+	fullName, isStack, err := resolveResource(st, baseName, spec, ext)
 	if err != nil {
 		return err
 	}
+	if isStack {
+		return showStackInfo(st, fullName)
+	}
+	return showSnippetInfo(st, fullName)
+}
 
-	fullName := utils.ParseResourceName(resource)
+// showHistory lists every stored version of a resource, newest first. A
+// name without extension covers the stack and snippets in any language.
+func showHistory(st *store.Store, baseName, ext string) error {
+	found := false
 
-	if store.IsSnippet(resource) {
-		return showSnippetInfo(st, fullName)
+	if ext == "" {
+		if versions := st.GetAllStackVersions(baseName); len(versions) > 0 {
+			found = true
+			fmt.Printf("📦 Stack: %s (%d version(s))\n", baseName, len(versions))
+			tags := versionTags(st.Tags(store.ResourceKey(baseName, "")))
+			for i := len(versions) - 1; i >= 0; i-- {
+				entry, _ := st.GetStack(store.FormatResourceName(baseName, versions[i], ""))
+				printHistoryLine(entry.Version, entry.CreatedAt, entry.Author, entry.Message, tags[entry.Version])
+			}
+		}
+	}
+
+	var exts []string
+	if ext != "" {
+		exts = []string{ext}
+	} else {
+		for _, name := range findMatchingSnippets(st, baseName) {
+			_, _, snippetExt := store.ParseResourceName(name)
+			if !slices.Contains(exts, snippetExt) {
+				exts = append(exts, snippetExt)
+			}
+		}
+	}
+
+	for _, snippetExt := range exts {
+		versions := st.GetAllVersions(baseName, snippetExt)
+		if len(versions) == 0 {
+			continue
+		}
+		if found {
+			fmt.Println()
+		}
+		found = true
+
+		key := store.ResourceKey(baseName, snippetExt)
+		fmt.Printf("📄 Snippet: %s (%d version(s))\n", key, len(versions))
+		tags := versionTags(st.Tags(key))
+		for i := len(versions) - 1; i >= 0; i-- {
+			entry, _ := st.GetSnippet(store.FormatResourceName(baseName, versions[i], snippetExt))
+			printHistoryLine(entry.Version, entry.CreatedAt, entry.Author, entry.Message, tags[entry.Version])
+		}
+	}
+
+	if !found {
+		return fmt.Errorf(utils.ErrResourceNotFound, "stack or snippet", baseName+ext)
+	}
+
+	fmt.Printf("\nRun 'bl info <name>@<version>' for details of one version\n")
+	return nil
+}
+
+// versionTags inverts a tag -> version map
+func versionTags(tags map[string]string) map[string][]string {
+	byVersion := make(map[string][]string)
+	for tag, version := range tags {
+		byVersion[version] = append(byVersion[version], tag)
+	}
+	for _, names := range byVersion {
+		sort.Strings(names)
+	}
+	return byVersion
+}
+
+// printHistoryLine prints one version of a history listing with its note
+// indented below it
+func printHistoryLine(version string, createdAt time.Time, author, message string, tags []string) {
+	line := fmt.Sprintf("   %-10s", version)
+	if !createdAt.IsZero() {
+		line += "  " + createdAt.Format("2006-01-02 15:04")
+	}
+	if author != "" {
+		line += "  " + author
+	}
+	if len(tags) > 0 {
+		line += "  [" + strings.Join(tags, ", ") + "]"
+	}
+	fmt.Println(line)
+
+	if message != "" {
+		fmt.Printf("   %-10s  %s\n", "", message)
 	}
-	return showStackInfo(st, fullName)
 }
 
 func showSnippetInfo(st *store.Store, name string) error {
-	path, ok := st.GetSnippet(name)
+	entry, ok := st.GetSnippet(name)
 	if !ok {
 		return fmt.Errorf(utils.ErrResourceNotFound, "snippet", name)
 	}
+	path := entry.Path
 
 	if !utils.FileExists(path) {
 		return fmt.Errorf(utils.ErrResourceNotFound, "snippet file", path)
@@ -71,19 +185,27 @@ func showSnippetInfo(st *store.Store, name string) error {
 	}
 
 	fmt.Printf("📄 Snippet: %s\n", name)
-	fmt.Printf("   Path:     %s\n", path)
-	fmt.Printf("   Size:     %d bytes\n", info.Size())
-	fmt.Printf("   Modified: %s\n", info.ModTime().Format("2006-01-02 15:04:05"))
-	fmt.Printf("   Type:     %s\n", filepath.Ext(path))
+	fmt.Printf("   Path:        %s\n", path)
+	fmt.Printf("   Version:     %s\n", entry.Version)
+	fmt.Printf("   Language:    %s\n", entry.Language)
+	printEntryField("Author", entry.Author)
+	printEntryField("Description", entry.Description)
+	printEntryField("Note", entry.Message)
+	fmt.Printf("   Size:        %d bytes\n", info.Size())
+	printEntryTimes(entry.CreatedAt, entry.UpdatedAt)
+	fmt.Printf("   Modified:    %s\n", info.ModTime().Format("2006-01-02 15:04:05"))
+	printEntryField("Checksum", entry.Checksum)
+	printIntegrity(store.VerifySnippet(entry), entry.Checksum != "")
 
 	return nil
 }
 
 func showStackInfo(st *store.Store, name string) error {
-	path, ok := st.GetStack(name)
+	entry, ok := st.GetStack(name)
 	if !ok {
 		return fmt.Errorf(utils.ErrResourceNotFound, "stack", name)
 	}
+	path := entry.Path
 
 	if !utils.IsDirectory(path) {
 		return fmt.Errorf(utils.ErrResourceNotFound, "stack directory", path)
@@ -111,9 +233,59 @@ func showStackInfo(st *store.Store, name string) error {
 
 	fmt.Printf("📦 Stack: %s\n", name)
 	fmt.Printf("   Path:        %s\n", path)
+	fmt.Printf("   Version:     %s\n", entry.Version)
+	printEntryField("Author", entry.Author)
+	printEntryField("Description", entry.Description)
+	printEntryField("Note", entry.Message)
 	fmt.Printf("   Files:       %d\n", fileCount)
 	fmt.Printf("   Directories: %d\n", dirCount-1) // -1 to exclude root
+	fmt.Printf("   Size:        %d bytes\n", entry.Size)
+	printEntryTimes(entry.CreatedAt, entry.UpdatedAt)
 	fmt.Printf("   Modified:    %s\n", info.ModTime().Format("2006-01-02 15:04:05"))
+	printEntryField("Checksum", entry.Checksum)
+	printIntegrity(store.VerifyStack(entry), entry.Checksum != "" || len(entry.Files) > 0)
+	if len(entry.Files) > 0 {
+		fmt.Println("   File digests:")
+		paths := make([]string, 0, len(entry.Files))
+		for p := range entry.Files {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			fmt.Printf("     %s  %s\n", entry.Files[p], p)
+		}
+	}
 
 	return nil
 }
+
+// printIntegrity reports whether the stored files still match the recorded
+// checksums
+func printIntegrity(err error, recorded bool) {
+	switch {
+	case !recorded:
+		printEntryField("Integrity", "no checksum recorded")
+	case err != nil:
+		printEntryField("Integrity", "⚠ "+err.Error())
+	default:
+		printEntryField("Integrity", "ok")
+	}
+}
+
+// printEntryField prints an optional info line, skipping empty values
+func printEntryField(label, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Printf("   %-12s %s\n", label+":", value)
+}
+
+// printEntryTimes prints the created/updated times recorded in the store index
+func printEntryTimes(createdAt, updatedAt time.Time) {
+	if !createdAt.IsZero() {
+		fmt.Printf("   Created:     %s\n", createdAt.Format("2006-01-02 15:04:05"))
+	}
+	if !updatedAt.IsZero() {
+		fmt.Printf("   Updated:     %s\n", updatedAt.Format("2006-01-02 15:04:05"))
+	}
+}
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -109,13 +108,8 @@ func createStackConfig(path string) error {
 	}
 
 	// Write to file
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
-	}
-
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+	if err := models.SaveStackConfig(filepath.Dir(path), &config); err != nil {
+		return err
 	}
 
 	fmt.Println("✓ Created boiler.stack.json")
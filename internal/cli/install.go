@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Recreate the files recorded in boiler.lock.json",
+	Long: `Replay the project's boiler.lock.json and write every snippet and stack it
+records, at the recorded version and with the recorded variable values.
+
+'bl add' writes boiler.lock.json next to the project-local store, or in the
+working directory, and updates it on every add. Check it in; in a fresh
+checkout 'bl install' rebuilds the files without prompting.
+
+Each resource is looked up in the project-local store, the global store and
+the registry cache, then fetched from the registry. The first copy whose
+checksum matches the one in the lock file is used, so a different build that
+happens to share the name and version is skipped, and refused if no copy
+matches.
+
+Files that already exist are kept; --force overwrites them. Resources whose
+checksum does not match are refused; --skip-verify installs them anyway.`,
+	Example: `  # Rebuild the files added to this project
+  bl install
+
+  # Overwrite files that already exist
+  bl install --force`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runInstall(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	installForce      bool
+	installSkipVerify bool
+)
+
+func init() {
+	installCmd.Flags().BoolVarP(&installForce, FlagForce, FlagForceShort, false, "Overwrite existing files")
+	installCmd.Flags().BoolVar(&installSkipVerify, FlagSkipVerify, false, DescSkipVerify)
+}
+
+func runInstall() error {
+	lock, err := requireLockfile()
+	if err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Installing from %s", lock.Path()))
+	if len(lock.Resources) == 0 {
+		fmt.Printf("Nothing to install, %s is empty\n", lock.Path())
+		return nil
+	}
+
+	scopes, err := readScopes(false, false)
+	if err != nil {
+		return err
+	}
+	stores, err := loadScopes(scopes)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, e := range lock.Resources {
+		written, kept, err := installEntry(stores, lock, e)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "✗ %s -> %s: %v\n", e.FullName(), e.Path, err)
+			logger.Info(fmt.Sprintf("Install of %s failed: %v", e.FullName(), err))
+			continue
+		}
+		fmt.Printf("✓ %s -> %s (%d written, %d kept)\n", e.FullName(), e.Path, written, kept)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d resources could not be installed", failed, len(lock.Resources))
+	}
+	return nil
+}
+
+// installEntry writes the files of one lock file entry and returns how many
+// were written and how many already existed
+func installEntry(stores []scopedStore, lock *lockfile.Lockfile, e lockfile.Entry) (int, int, error) {
+	st, err := lockedStore(stores, e)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var src, checksum string
+	var verifyErr error
+	if e.Kind == lockfile.KindStack {
+		entry, _ := st.GetStack(e.FullName())
+		src, checksum, verifyErr = entry.Path, entry.Checksum, store.VerifyStack(entry)
+	} else {
+		entry, _ := st.GetSnippet(e.FullName())
+		src, checksum, verifyErr = entry.Path, entry.Checksum, store.VerifySnippet(entry)
+	}
+
+	if e.Checksum != "" && !strings.EqualFold(checksum, e.Checksum) {
+		if !installSkipVerify {
+			return 0, 0, fmt.Errorf("stored checksum %s does not match %s in the lock file; use --%s to install it anyway",
+				checksum, e.Checksum, FlagSkipVerify)
+		}
+		fmt.Printf("⚠ '%s' does not match the checksum in the lock file; installing it anyway\n", e.FullName())
+	}
+	if err := checkIntegrity(verifyErr, installSkipVerify); err != nil {
+		return 0, 0, err
+	}
+
+	dest := lock.Abs(e.Path)
+	if e.Kind != lockfile.KindStack {
+		if utils.FileExists(dest) && !installForce {
+			return 0, 1, nil
+		}
+		if err := utils.CopyFileWithVariables(src, dest, e.Variables); err != nil {
+			return 0, 0, fmt.Errorf("failed to write snippet: %w", err)
+		}
+		return 1, 0, nil
+	}
+
+	files, err := stackFiles(src, stackIgnorePatterns)
+	if err != nil {
+		return 0, 0, err
+	}
+	names := make([]string, 0, len(files))
+	for rel := range files {
+		names = append(names, rel)
+	}
+	sort.Strings(names)
+
+	written, kept := 0, 0
+	for _, rel := range names {
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+		if utils.FileExists(target) && !installForce {
+			kept++
+			continue
+		}
+		if err := utils.CopyFile(files[rel], target); err != nil {
+			return written, kept, err
+		}
+		written++
+	}
+	return written, kept, nil
+}
+
+// lockedStore finds the store holding the exact version a lock file entry
+// names, with the checksum it was added with: the local and global stores,
+// the registry cache, and last the registry itself. A store holding another
+// build of the same version is only returned when no copy matches, so the
+// caller can report the mismatch or, with --skip-verify, install it anyway.
+func lockedStore(stores []scopedStore, e lockfile.Entry) (*store.Store, error) {
+	// checksum returns the recorded checksum of the entry's version in st
+	checksum := func(st *store.Store) (string, bool) {
+		if e.Kind == lockfile.KindStack {
+			if entry, ok := st.GetStack(e.FullName()); ok {
+				return entry.Checksum, true
+			}
+		} else if entry, ok := st.GetSnippet(e.FullName()); ok {
+			return entry.Checksum, true
+		}
+		return "", false
+	}
+
+	var mismatch *store.Store
+	find := func(st *store.Store) bool {
+		sum, ok := checksum(st)
+		if !ok {
+			return false
+		}
+		// Entries recorded without a checksum take any copy
+		if e.Checksum == "" || strings.EqualFold(sum, e.Checksum) {
+			return true
+		}
+		logger.Info(fmt.Sprintf("Skipping %s in %s: checksum %s differs from the lock file", e.FullName(), st.Root(), sum))
+		if mismatch == nil {
+			mismatch = st
+		}
+		return false
+	}
+
+	for _, s := range stores {
+		if find(s.store) {
+			return s.store, nil
+		}
+	}
+
+	cache, err := openRegistryCache()
+	if err == nil && find(cache.Store()) {
+		// The signing policy may have changed since it was fetched
+		if err := verifyCachedResource(cache.Store(), e); err != nil {
+			return nil, err
+		}
+		return cache.Store(), nil
+	}
+
+	st, _, _, err := fetchRemoteResource(e.FullName())
+	switch {
+	case err == nil && find(st):
+		return st, nil
+	case mismatch != nil:
+		if cache != nil && mismatch == cache.Store() {
+			if err := verifyCachedResource(mismatch, e); err != nil {
+				return nil, err
+			}
+		}
+		return mismatch, nil
+	case err != nil:
+		return nil, fmt.Errorf("not in any store, and fetching it failed: %w", err)
+	}
+	return nil, fmt.Errorf("the registry has no %s '%s'", e.Kind, e.FullName())
+}
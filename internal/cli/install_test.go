@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+func TestLockedStoreMatchesChecksum(t *testing.T) {
+	project := useTestConfig(t)
+	localRoot, err := store.InitLocal(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same version with different content in the local and global store
+	put := func(root, content string) (*store.Store, string) {
+		writeFiles(t, root, map[string]string{"snippets/js/logger@1.0.0.js": content})
+		st, err := utils.LoadStore(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, err := store.NewSnippetEntry("logger@1.0.0.js", filepath.Join(root, "snippets", "js", "logger@1.0.0.js"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.AddSnippet(entry); err != nil {
+			t.Fatal(err)
+		}
+		return st, entry.Checksum
+	}
+	local, localSum := put(localRoot, "console.log('local')\n")
+	global, globalSum := put(cfg.Paths.Store, "console.log('global')\n")
+	stores := []scopedStore{{store: local, scope: localScopeAt(localRoot)}, {store: global, scope: globalScope()}}
+
+	e := lockfile.Entry{Kind: lockfile.KindSnippet, Name: "logger", Version: "1.0.0", Extension: ".js"}
+	for _, tt := range []struct {
+		checksum string
+		want     *store.Store
+	}{
+		{globalSum, global},
+		{localSum, local},
+		// No copy matches: the first one is returned for the caller to refuse
+		{"0000", local},
+	} {
+		e.Checksum = tt.checksum
+		got, err := lockedStore(stores, e)
+		if err != nil {
+			t.Fatalf("lockedStore(checksum %s) error = %v", tt.checksum, err)
+		}
+		if got != tt.want {
+			t.Errorf("lockedStore(checksum %s) = %s, want %s", tt.checksum, got.Root(), tt.want.Root())
+		}
+	}
+}
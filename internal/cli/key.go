@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rishiyaduwanshi/boiler/internal/config"
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
+	"github.com/spf13/cobra"
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage signing keys",
+	Long: `Manage the ed25519 keys used to sign and verify shared resources.
+
+With a key from 'bl key gen', 'bl export' signs bundles and 'bl publish'
+signs uploads. 'bl import' and 'bl add --remote' check signatures against
+the trusted keys in boiler.conf.json and follow the 'signing.policy':
+
+  warn     warn about unsigned or untrusted content (default)
+  require  refuse it
+  off      do not check
+
+A signature that does not match the content is always refused.`,
+	Example: `  # Create your key and print the public key to share
+  bl key gen
+
+  # Trust a colleague's key
+  bl key trust jane ed25519:3q2+7w...
+
+  # Require trusted signatures
+  bl conf --edit    # set "signing": {"policy": "require"}`,
+}
+
+var keyGenCmd = &cobra.Command{
+	Use:   "gen",
+	Short: "Generate your signing key",
+	Long: `Generate an ed25519 key pair for signing exports and publishes.
+
+The private key is written to ~/.boiler/keys/boiler.key (or 'signing.key'
+in boiler.conf.json), the public key next to it with a .pub extension.
+Share the public key; others add it with 'bl key trust'. Your own key is
+trusted automatically.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := generateKey(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var keyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print your public key",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := signingKey()
+		if err == nil && key == nil {
+			err = fmt.Errorf("no signing key. Run 'bl key gen' to create one")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		pub := key.Public().(ed25519.PublicKey)
+		fmt.Println(signing.EncodePublicKey(pub))
+	},
+}
+
+var keyTrustCmd = &cobra.Command{
+	Use:   "trust <name> <public key>",
+	Short: "Trust signatures made with a public key",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := trustKey(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var keyUntrustCmd = &cobra.Command{
+	Use:   "untrust <name>",
+	Short: "Stop trusting a key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := untrustKey(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var keyListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List trusted keys",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(cfg.Signing.TrustedKeys) == 0 {
+			fmt.Println("No trusted keys. Add one with 'bl key trust <name> <public key>'")
+			return
+		}
+		fmt.Println("\n🔑 Trusted keys:")
+		for _, t := range cfg.Signing.TrustedKeys {
+			id := "invalid key"
+			if pub, err := signing.ParsePublicKey(t.Key); err == nil {
+				id = signing.KeyID(pub)
+			}
+			fmt.Printf("  • %s (%s)\n", t.Name, id)
+		}
+		fmt.Printf("\nPolicy: %s\n", signingPolicy())
+	},
+}
+
+// keyPath returns the private key file
+func keyPath() string {
+	if cfg.Signing.Key != "" {
+		return config.ExpandPath(cfg.Signing.Key)
+	}
+	return filepath.Join(cfg.Paths.Root, "keys", "boiler.key")
+}
+
+// signingKey returns the private key, or nil if none was generated
+func signingKey() (ed25519.PrivateKey, error) {
+	key, err := signing.LoadPrivateKey(keyPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return key, err
+}
+
+// signingPolicy returns the configured policy, warn if unset
+func signingPolicy() string {
+	switch cfg.Signing.Policy {
+	case signing.PolicyOff, signing.PolicyRequire:
+		return cfg.Signing.Policy
+	}
+	return signing.PolicyWarn
+}
+
+// trustedKeys returns the trusted keys plus your own
+func trustedKeys() []signing.TrustedKey {
+	trusted := cfg.Signing.TrustedKeys
+	if key, err := signingKey(); err == nil && key != nil {
+		own := signing.EncodePublicKey(key.Public().(ed25519.PublicKey))
+		trusted = append(append([]signing.TrustedKey{}, trusted...), signing.TrustedKey{Name: "you", Key: own})
+	}
+	return trusted
+}
+
+// checkSigned applies the signing policy to the outcome of a verification
+// of what, e.g. "bundle 'team.blpack'". It fails only under 'require'.
+func checkSigned(what string, signer string, err error) error {
+	policy := signingPolicy()
+	if policy == signing.PolicyOff {
+		return nil
+	}
+	if err == nil {
+		fmt.Printf("🔏 %s is signed by %s\n", what, signer)
+		return nil
+	}
+
+	logger.Info(fmt.Sprintf("Signature check failed for %s: %v", what, err))
+	if policy == signing.PolicyRequire || errors.Is(err, signing.ErrInvalid) {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	fmt.Printf("⚠ %s is %v\n", what, err)
+	return nil
+}
+
+func generateKey() error {
+	path := keyPath()
+	if _, err := os.Stat(path); err == nil && !keyForce {
+		return fmt.Errorf("a key already exists at %s. Use --force to replace it", path)
+	}
+
+	pub, err := signing.GenerateKey(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Created signing key at %s\n", path)
+	fmt.Printf("\nYour public key (share it with 'bl key trust <name> <key>'):\n  %s\n", pub)
+	logger.Info(fmt.Sprintf("Signing key generated: %s", path))
+	return nil
+}
+
+func trustKey(name, key string) error {
+	if _, err := signing.ParsePublicKey(key); err != nil {
+		return err
+	}
+
+	trusted := cfg.Signing.TrustedKeys[:0:0]
+	for _, t := range cfg.Signing.TrustedKeys {
+		if t.Name != name {
+			trusted = append(trusted, t)
+		}
+	}
+	cfg.Signing.TrustedKeys = append(trusted, signing.TrustedKey{Name: name, Key: key})
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("✓ Trusting key '%s'\n", name)
+	logger.Info(fmt.Sprintf("Trusted key added: %s", name))
+	return nil
+}
+
+func untrustKey(name string) error {
+	trusted := cfg.Signing.TrustedKeys[:0:0]
+	for _, t := range cfg.Signing.TrustedKeys {
+		if t.Name != name {
+			trusted = append(trusted, t)
+		}
+	}
+	if len(trusted) == len(cfg.Signing.TrustedKeys) {
+		return fmt.Errorf("no trusted key named '%s'", name)
+	}
+
+	cfg.Signing.TrustedKeys = trusted
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("✓ No longer trusting key '%s'\n", name)
+	logger.Info(fmt.Sprintf("Trusted key removed: %s", name))
+	return nil
+}
+
+var keyForce bool
+
+func init() {
+	keyCmd.AddCommand(keyGenCmd, keyShowCmd, keyTrustCmd, keyUntrustCmd, keyListCmd)
+	keyGenCmd.Flags().BoolVarP(&keyForce, "force", "f", false, "Replace an existing key")
+}
@@ -3,7 +3,6 @@ package cli
 import (
 	"fmt"
 
-	"github.com/rishiyaduwanshi/boiler/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -14,7 +13,11 @@ var listCmd = &cobra.Command{
 	Long: `List all stored snippets and stacks with their version numbers.
 
 By default, shows both snippets and stacks. Use flags to filter by type.
-All resources are shown with version numbers included.`,
+All resources are shown with version numbers included, followed by the
+description and author recorded in the store index.
+
+When a project-local .boiler/ store is found, it is listed together with the
+global store and every entry is marked [local] or [global].`,
 	Example: `  # List everything
   bl ls
 
@@ -22,9 +25,18 @@ All resources are shown with version numbers included.`,
   bl ls --snippets
 
   # List only stacks
-  bl ls --stacks`,
+  bl ls --stacks
+
+  # List only the project-local store
+  bl ls --local`,
 	Run: func(cmd *cobra.Command, args []string) {
-		st, err := utils.LoadStore(cfg.Paths.Store)
+		scopes, err := readScopes(listLocal && !listBoth, listGlobal && !listBoth)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		stores, err := loadScopes(scopes)
 		if err != nil {
 			fmt.Printf("Error loading store: %v\n", err)
 			return
@@ -34,38 +46,72 @@ All resources are shown with version numbers included.`,
 
 		if listSnippets || showAll {
 			fmt.Println("\n📄 Snippets:")
-			snippets := st.ListSnippets()
-			if len(snippets) == 0 {
-				fmt.Println("  No snippets found")
-			} else {
-				for _, name := range snippets {
-					fmt.Printf("  • %s\n", name)
+			found := false
+			for _, sst := range stores {
+				for _, entry := range sst.store.SnippetEntries() {
+					printListEntry(entry.FullName(), entry.Description, entry.Author, sst.source(len(stores)))
+					found = true
 				}
 			}
+			if !found {
+				fmt.Println("  No snippets found")
+			}
 		}
 
 		if listStacks || showAll {
 			fmt.Println("\n📦 Stacks:")
-			stacks := st.ListStacks()
-			if len(stacks) == 0 {
-				fmt.Println("  No stacks found")
-			} else {
-				for _, name := range stacks {
-					fmt.Printf("  • %s\n", name)
+			found := false
+			for _, sst := range stores {
+				for _, entry := range sst.store.StackEntries() {
+					printListEntry(entry.FullName(), entry.Description, entry.Author, sst.source(len(stores)))
+					found = true
 				}
 			}
+			if !found {
+				fmt.Println("  No stacks found")
+			}
 		}
 
 		fmt.Println()
 	},
 }
 
+// listEntry is one line of a listing or search result
+type listEntry struct {
+	name        string
+	description string
+	author      string
+	source      string
+}
+
+// printListEntry prints one resource line with its description and author,
+// if known, and the store it came from when more than one store is listed
+func printListEntry(name, description, author, source string) {
+	line := fmt.Sprintf("  • %s", name)
+	if description != "" {
+		line += " - " + description
+	}
+	if author != "" {
+		line += fmt.Sprintf(" (%s)", author)
+	}
+	if source != "" {
+		line += fmt.Sprintf(" [%s]", source)
+	}
+	fmt.Println(line)
+}
+
 var (
 	listSnippets bool
 	listStacks   bool
+	listLocal    bool
+	listGlobal   bool
+	listBoth     bool
 )
 
 func init() {
 	listCmd.Flags().BoolVarP(&listSnippets, "snippets", "n", false, "List snippets")
 	listCmd.Flags().BoolVarP(&listStacks, "stacks", "k", false, "List stacks")
+	listCmd.Flags().BoolVarP(&listLocal, FlagLocal, FlagLocalShort, false, "List only the project-local store")
+	listCmd.Flags().BoolVarP(&listGlobal, FlagGlobal, FlagGlobalShort, false, "List only the global store")
+	listCmd.Flags().BoolVarP(&listBoth, FlagBoth, FlagBothShort, false, "List the local and the global store (default)")
 }
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
+)
+
+// openLockfile loads the project's lock file: the nearest one from the
+// working directory up, or else a new one next to the project-local store,
+// or in the working directory when there is none
+func openLockfile() (*lockfile.Lockfile, error) {
+	if path, ok := lockfile.Find("."); ok {
+		return lockfile.Load(path)
+	}
+	dir := "."
+	if sc, ok := findLocalScope(); ok {
+		dir = filepath.Dir(sc.Root)
+	}
+	return lockfile.Load(filepath.Join(dir, lockfile.FileName))
+}
+
+// requireLockfile loads the nearest lock file for commands that only make
+// sense once something was added
+func requireLockfile() (*lockfile.Lockfile, error) {
+	path, ok := lockfile.Find(".")
+	if !ok {
+		return nil, fmt.Errorf("no %s found; resources added with 'bl add' are recorded there", lockfile.FileName)
+	}
+	return lockfile.Load(path)
+}
+
+// recordAdd records a resource added to dest, which wrote files, in the
+// project's lock file. Adds outside of the project are not recorded, with a
+// warning since 'bl install' will not reproduce them.
+func recordAdd(e lockfile.Entry, dest string, files []string) error {
+	lock, err := openLockfile()
+	if err != nil {
+		return err
+	}
+	if e.Path, err = lock.Rel(dest); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Not recorded in %s, 'bl install' will not recreate it: %v\n", lock.Path(), err)
+		logger.Info(fmt.Sprintf("Not recording %s in %s: %v", e.FullName(), lock.Path(), err))
+		return nil
+	}
+	for _, f := range files {
+		rel, err := lock.Rel(f)
+		if err != nil {
+			return err
+		}
+		e.Files = append(e.Files, rel)
+	}
+
+	lock.Put(e)
+	if err := lock.Save(); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Recorded %s -> %s in %s", e.FullName(), e.Path, lock.Path()))
+	return nil
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
+)
+
+func TestRecordAdd(t *testing.T) {
+	project := useTestConfig(t)
+	e := lockfile.Entry{Kind: lockfile.KindSnippet, Name: "logger", Version: "1.0.0", Extension: ".js", Checksum: "abc"}
+
+	dest := filepath.Join(project, "src", "logger.js")
+	if err := recordAdd(e, dest, []string{dest}); err != nil {
+		t.Fatalf("recordAdd() error = %v", err)
+	}
+
+	// Outside the project: not recorded, but the user is told
+	outside := filepath.Join(filepath.Dir(project), "elsewhere", "logger.js")
+	var err error
+	stderr := captureStderr(t, func() {
+		err = recordAdd(e, outside, []string{outside})
+	})
+	if err != nil {
+		t.Fatalf("recordAdd(outside) error = %v", err)
+	}
+	if !strings.Contains(stderr, "Not recorded") {
+		t.Errorf("recordAdd(outside) warned %q, want a warning that it is not recorded", stderr)
+	}
+
+	lock, err := lockfile.Load(filepath.Join(project, lockfile.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lock.Resources) != 1 || lock.Resources[0].Path != "src/logger.js" {
+		t.Fatalf("lock file holds %+v, want only src/logger.js", lock.Resources)
+	}
+	if got := lock.Resources[0].Files; len(got) != 1 || got[0] != "src/logger.js" {
+		t.Errorf("recorded files = %v, want [src/logger.js]", got)
+	}
+}
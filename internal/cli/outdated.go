@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List added resources that have newer versions in the store",
+	Long: `Compare the versions recorded in boiler.lock.json with the store and list
+every added snippet and stack that has a newer stored version.
+
+Resources are looked up like 'bl add' does: in the project-local store
+first, then in the global one. Use 'bl update <name>' to bring one up to
+date.`,
+	Example: `  # What could be updated?
+  bl outdated`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runOutdated(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func runOutdated() error {
+	lock, err := requireLockfile()
+	if err != nil {
+		return err
+	}
+	scopes, err := readScopes(false, false)
+	if err != nil {
+		return err
+	}
+
+	outdated := 0
+	for _, e := range lock.Resources {
+		latest, err := latestVersion(scopes, e)
+		if err != nil {
+			return err
+		}
+		if latest == "" {
+			logger.Info(fmt.Sprintf("%s is in %s but not in any store", e.FullName(), lock.Path()))
+			continue
+		}
+		if store.CompareVersions(latest, e.Version) <= 0 {
+			continue
+		}
+		if outdated == 0 {
+			fmt.Printf("%-32s %-20s %-10s %s\n", "PATH", "RESOURCE", "CURRENT", "LATEST")
+		}
+		outdated++
+		fmt.Printf("%-32s %-20s %-10s %s\n", e.Path, e.Key(), e.Version, latest)
+	}
+
+	if outdated == 0 {
+		fmt.Println("✓ Everything in " + lockfile.FileName + " is up to date")
+		return nil
+	}
+	fmt.Printf("\n%d outdated. Run 'bl update <name>' to update.\n", outdated)
+	return nil
+}
+
+// latestVersion returns the highest stored version of a lock file entry's
+// resource, or "" if no store has it
+func latestVersion(scopes []storeScope, e lockfile.Entry) (string, error) {
+	st, _, err := lookupStore(scopes, e.Name, e.Extension)
+	if err != nil {
+		return "", err
+	}
+	var versions []string
+	if e.Kind == lockfile.KindStack {
+		versions = st.GetAllStackVersions(e.Name)
+	} else {
+		versions = st.GetAllVersions(e.Name, e.Extension)
+	}
+	if len(versions) == 0 {
+		return "", nil
+	}
+	return versions[len(versions)-1], nil
+}
@@ -17,7 +17,8 @@ Shows:
   - Snippets - Snippet storage location
   - Stacks - Stack storage location
   - Logs - Log file directory
-  - Bin - Executable location`,
+  - Bin - Executable location
+  - Local store - Project-local .boiler/ store, if one is found`,
 	Example: `  # Show all paths
   bl path
 
@@ -30,5 +31,8 @@ Shows:
 		fmt.Printf("Stacks:      %s\n", cfg.Paths.Stacks)
 		fmt.Printf("Logs:        %s\n", cfg.Paths.Logs)
 		fmt.Printf("Bin:         %s\n", cfg.Paths.Bin)
+		if local, ok := findLocalScope(); ok {
+			fmt.Printf("Local store: %s\n", local.Root)
+		}
 	},
 }
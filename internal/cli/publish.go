@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/archive"
+	"github.com/rishiyaduwanshi/boiler/internal/models"
+	"github.com/rishiyaduwanshi/boiler/internal/registry"
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var publishCmd = &cobra.Command{
+	Use:   "publish <path|resource>",
+	Short: "Publish a snippet or stack to the registry",
+	Long: `Upload a snippet or stack to the registry in boiler.conf.json.
+
+The argument is a file or folder, read like 'bl store' reads it, or a stored
+resource such as logger@1.2.0.js, which is published with its version.
+
+The registry applies the same versioning rules as 'bl store': a new snippet
+starts at 1.0.0 and later uploads bump the latest published version (patch
+by default, see --bump). Stacks are published with the version in their
+boiler.stack.json. Publishing an existing version fails.
+
+Publishing needs a write token, set as 'registryToken' in boiler.conf.json
+or in the BL_REGISTRY_TOKEN environment variable. With a key from
+'bl key gen', the upload is signed.`,
+	Example: `  # Publish a snippet file as the next minor version
+  bl publish ./utils/logger.js --bump minor -m "Add JSON output"
+
+  # Publish a stack folder
+  bl publish ./my-template
+
+  # Publish a stored version
+  bl publish logger@1.2.0.js`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger.Info(fmt.Sprintf("Publishing: %s", args[0]))
+
+		if err := publishResource(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func publishResource(arg string) error {
+	client, err := newRegistryClient()
+	if err != nil {
+		return err
+	}
+
+	var req registry.PublishRequest
+	switch {
+	case utils.IsDirectory(arg):
+		req, err = stackPublishRequest(arg)
+	case utils.FileExists(arg):
+		req, err = snippetPublishRequest(arg)
+	default:
+		req, err = storedPublishRequest(arg)
+	}
+	if err != nil {
+		return err
+	}
+
+	req.Bump = publishBump
+	if publishMessage != "" {
+		req.Message = publishMessage
+	}
+	if publishDescription != "" {
+		req.Description = publishDescription
+	}
+
+	if req.Version != "" {
+		if req.Version, err = store.NormalizeVersion(req.Version); err != nil {
+			return err
+		}
+	}
+
+	key, err := signingKey()
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		// The signature covers the version, so pick the one the registry
+		// would assign
+		if req.Version == "" {
+			idx, err := client.Index()
+			if err != nil {
+				return err
+			}
+			req.Version, err = idx.NextVersion(req.Kind, req.Name, req.Extension, req.Bump)
+			if err != nil {
+				return err
+			}
+		}
+		digest, err := registry.ContentDigest(req.Kind, req.Content)
+		if err != nil {
+			return err
+		}
+		fullName := store.FormatResourceName(req.Name, req.Version, req.Extension)
+		req.Signature = signing.Sign(key, signing.ResourceMessage(req.Kind, fullName, digest))
+	}
+
+	res, err := client.Publish(req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Published %s '%s' to %s\n", res.Kind, res.FullName(), client.BaseURL)
+	if res.Signature != nil {
+		fmt.Printf("  Signed with key %s\n", res.Signature.KeyID)
+	}
+	logger.Info(fmt.Sprintf("Published %s to %s", res.FullName(), client.BaseURL))
+	return nil
+}
+
+// snippetPublishRequest reads a snippet file and its metadata comments
+func snippetPublishRequest(path string) (registry.PublishRequest, error) {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return registry.PublishRequest{}, fmt.Errorf(utils.ErrSnippetNeedExt)
+	}
+
+	meta, err := utils.ParseSnippetMetadata(path)
+	if err != nil {
+		return registry.PublishRequest{}, fmt.Errorf("failed to parse snippet metadata: %w", err)
+	}
+	if err := utils.ValidateSnippetMetadata(meta); err != nil {
+		return registry.PublishRequest{}, fmt.Errorf("invalid snippet metadata: %w", err)
+	}
+	if err := meta.CheckVariables(); err != nil {
+		return registry.PublishRequest{}, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return registry.PublishRequest{}, err
+	}
+
+	name := publishName
+	if name == "" {
+		name = meta.Name
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), ext)
+	}
+
+	return registry.PublishRequest{
+		Kind:        registry.KindSnippet,
+		Name:        name,
+		Extension:   ext,
+		Author:      meta.Author,
+		Description: meta.Description,
+		Content:     content,
+	}, nil
+}
+
+// stackPublishRequest packs a stack folder with its boiler.stack.json
+func stackPublishRequest(dir string) (registry.PublishRequest, error) {
+	stackConfig, err := models.ParseStackConfig(dir)
+	if err != nil {
+		return registry.PublishRequest{}, err
+	}
+	if stackConfig.ID == "" || stackConfig.Version == "" {
+		return registry.PublishRequest{}, fmt.Errorf("'id' and 'version' are required in boiler.stack.json")
+	}
+
+	// Same files as 'bl store' keeps, config included
+	ignore := append(append([]string{}, stackConfig.Ignore...), store.LocalDir)
+	var buf bytes.Buffer
+	if err := archive.Pack(&buf, dir, ignore); err != nil {
+		return registry.PublishRequest{}, err
+	}
+
+	return registry.PublishRequest{
+		Kind:        registry.KindStack,
+		Name:        stackConfig.ID,
+		Version:     strings.TrimSpace(stackConfig.Version),
+		Author:      stackConfig.Author,
+		Description: stackConfig.Description,
+		Content:     buf.Bytes(),
+	}, nil
+}
+
+// storedPublishRequest publishes a stored version as is
+func storedPublishRequest(resource string) (registry.PublishRequest, error) {
+	baseName, spec, ext := store.ParseResourceName(resource)
+	scopes, err := readScopes(false, false)
+	if err != nil {
+		return registry.PublishRequest{}, err
+	}
+	st, _, err := lookupStore(scopes, baseName, ext)
+	if err != nil {
+		return registry.PublishRequest{}, err
+	}
+	fullName, isStack, err := resolveResource(st, baseName, spec, ext)
+	if err != nil {
+		return registry.PublishRequest{}, err
+	}
+
+	if isStack {
+		entry, _ := st.GetStack(fullName)
+		var buf bytes.Buffer
+		if err := archive.Pack(&buf, entry.Path, nil); err != nil {
+			return registry.PublishRequest{}, err
+		}
+		return registry.PublishRequest{
+			Kind:        registry.KindStack,
+			Name:        entry.Name,
+			Version:     entry.Version,
+			Author:      entry.Author,
+			Description: entry.Description,
+			Message:     entry.Message,
+			Content:     buf.Bytes(),
+		}, nil
+	}
+
+	entry, _ := st.GetSnippet(fullName)
+	content, err := os.ReadFile(entry.Path)
+	if err != nil {
+		return registry.PublishRequest{}, err
+	}
+	return registry.PublishRequest{
+		Kind:        registry.KindSnippet,
+		Name:        entry.Name,
+		Version:     entry.Version,
+		Extension:   entry.Extension,
+		Author:      entry.Author,
+		Description: entry.Description,
+		Message:     entry.Message,
+		Content:     content,
+	}, nil
+}
+
+var (
+	publishName        string
+	publishBump        string
+	publishMessage     string
+	publishDescription string
+)
+
+func init() {
+	publishCmd.Flags().StringVar(&publishName, "name", "", "Name for the snippet (default: from metadata or file name)")
+	publishCmd.Flags().StringVar(&publishBump, "bump", store.BumpPatch, "Version part the registry bumps for a new snippet version (major, minor, patch)")
+	publishCmd.Flags().StringVarP(&publishMessage, "message", "m", "", "Note on what changed in this version")
+	publishCmd.Flags().StringVarP(&publishDescription, "description", "d", "", "Description")
+}
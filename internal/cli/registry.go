@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/rishiyaduwanshi/boiler/internal/config"
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
+	"github.com/rishiyaduwanshi/boiler/internal/registry"
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Host a registry for your team",
+	Long: `Commands for running a Boiler registry.
+
+A registry shares snippets and stacks over HTTP. Teammates point the
+'registry' field of their boiler.conf.json at it and use 'bl add --remote',
+'bl search --remote' and 'bl publish'.`,
+}
+
+var registryServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a store directory as a registry",
+	Long: `Serve a store directory over the registry protocol.
+
+Everything in the store can be listed, searched and downloaded; stacks are
+sent as tar.gz archives. 'bl publish' uploads go through the same versioning
+rules as 'bl store' and land in the served store.
+
+Access is controlled by bearer tokens in the server config file
+(~/.boiler/boiler.registry.json by default):
+
+  {
+    "public": false,
+    "tokens": [
+      { "name": "ci",   "token": "<random string>", "access": "read" },
+      { "name": "jane", "token": "<random string>", "access": "write" }
+    ]
+  }
+
+Read tokens can list, search and download; write tokens can also publish.
+With "public": true, reading needs no token. Without a config file the
+registry is public and read-only.
+
+Clients send their token from 'registryToken' in boiler.conf.json or the
+BL_REGISTRY_TOKEN environment variable.`,
+	Example: `  # Serve the global store on port 7070
+  bl registry serve
+
+  # Serve a dedicated team store
+  bl registry serve --store /srv/boiler --addr :8080 --config /etc/boiler/registry.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := serveRegistry(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func serveRegistry() error {
+	sc := globalScope()
+	if registryStore != "" {
+		root, err := filepath.Abs(config.ExpandPath(registryStore))
+		if err != nil {
+			return err
+		}
+		sc = storeScope{Name: "served", Root: root, Snippets: filepath.Join(root, "snippets"), Stacks: filepath.Join(root, "stacks")}
+	}
+	if _, err := sc.load(); err != nil {
+		return err
+	}
+
+	confPath := registryConfig
+	if confPath == "" {
+		confPath = filepath.Join(cfg.Paths.Root, "boiler.registry.json")
+	}
+	conf, err := registry.LoadServerConfig(config.ExpandPath(confPath))
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("⚠ No server config at %s: serving public and read-only\n", confPath)
+		conf = &registry.ServerConfig{Public: true}
+	} else if err != nil {
+		return err
+	}
+
+	srv := registry.NewServer(sc.Root, sc.Snippets, sc.Stacks, conf)
+	srv.Logf = func(format string, args ...any) {
+		line := fmt.Sprintf(format, args...)
+		fmt.Println(line)
+		logger.Info("registry: " + line)
+	}
+
+	fmt.Printf("✓ Serving %s on %s\n", sc.Root, registryAddr)
+	return http.ListenAndServe(registryAddr, srv.Handler())
+}
+
+// newRegistryClient returns a client for the registry in the config. The
+// token comes from BL_REGISTRY_TOKEN or registryToken in the config.
+func newRegistryClient() (*registry.Client, error) {
+	client, err := registry.NewClient(cfg.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Token = cfg.RegistryToken
+	if token := os.Getenv("BL_REGISTRY_TOKEN"); token != "" {
+		client.Token = token
+	}
+	return client, nil
+}
+
+// openRegistryCache opens the cache of fetched resources under the Boiler root
+func openRegistryCache() (*registry.Cache, error) {
+	return registry.OpenCache(filepath.Join(cfg.Paths.Root, "cache", "registry"))
+}
+
+// addRemoteResource resolves a resource in the registry index, fetches it
+// into the cache and adds it from there like a stored resource. Without a
+// version the latest published version is used.
+func addRemoteResource(resource, destPath string) error {
+	st, fullName, isStack, err := fetchRemoteResource(resource)
+	if err != nil {
+		return err
+	}
+	if isStack {
+		return addStack(st, fullName, destPath)
+	}
+	return addSnippet(st, fullName, destPath)
+}
+
+// fetchRemoteResource resolves a resource in the registry index and fetches
+// it into the cache. It returns the cache store, the full name there and
+// whether it is a stack.
+func fetchRemoteResource(resource string) (*store.Store, string, bool, error) {
+	client, err := newRegistryClient()
+	if err != nil {
+		return nil, "", false, err
+	}
+	idx, err := client.Index()
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	baseName, spec, ext := store.ParseResourceName(resource)
+	r, err := idx.Resolve(baseName, spec, ext)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	cache, err := openRegistryCache()
+	if err != nil {
+		return nil, "", false, err
+	}
+	fmt.Printf("⬇ Fetching '%s' from %s\n", r.FullName(), client.BaseURL)
+	// The signature is checked before the copy enters the cache, so a
+	// rejected resource is never served from there later
+	fullName, err := cache.Fetch(client, r, func(digest string) error {
+		return verifyRemoteResource(r, digest)
+	})
+	if err != nil {
+		return nil, "", false, err
+	}
+	logger.Info(fmt.Sprintf("Fetched %s from registry %s (sha256 %s)", fullName, client.BaseURL, r.Checksum))
+	return cache.Store(), fullName, r.Kind == registry.KindStack, nil
+}
+
+// verifyRemoteResource checks the resource's signature over the resolved
+// version and the content digest of the fetched copy against the trusted
+// keys
+func verifyRemoteResource(r registry.Resource, digest string) error {
+	message := signing.ResourceMessage(r.Kind, r.FullName(), digest)
+	signer, err := signing.Verify(r.Signature, message, trustedKeys())
+	return checkSigned(fmt.Sprintf("'%s'", r.FullName()), signer, err)
+}
+
+// verifyCachedResource checks the signature recorded with a cached copy of
+// a lock file entry, like verifyRemoteResource did when it was fetched
+func verifyCachedResource(st *store.Store, e lockfile.Entry) error {
+	var digest string
+	var sig *signing.Signature
+	if e.Kind == lockfile.KindStack {
+		if entry, ok := st.GetStack(e.FullName()); ok {
+			digest, sig = entry.Checksum, entry.Signature
+		}
+	} else if entry, ok := st.GetSnippet(e.FullName()); ok {
+		digest, sig = entry.Checksum, entry.Signature
+	}
+
+	message := signing.ResourceMessage(e.Kind, e.FullName(), digest)
+	signer, err := signing.Verify(sig, message, trustedKeys())
+	return checkSigned(fmt.Sprintf("'%s'", e.FullName()), signer, err)
+}
+
+// searchRegistry prints the registry's search results like 'bl search'
+func searchRegistry(query string) error {
+	client, err := newRegistryClient()
+	if err != nil {
+		return err
+	}
+
+	kind := ""
+	if searchSnippets && !searchStacks {
+		kind = registry.KindSnippet
+	} else if searchStacks && !searchSnippets {
+		kind = registry.KindStack
+	}
+
+	results, err := client.Search(query, kind)
+	if err != nil {
+		return err
+	}
+
+	var snippets, stacks []listEntry
+	for _, r := range results {
+		entry := listEntry{r.FullName(), r.Description, r.Author, "remote"}
+		if r.Kind == registry.KindStack {
+			stacks = append(stacks, entry)
+		} else {
+			snippets = append(snippets, entry)
+		}
+	}
+
+	if len(snippets) == 0 && len(stacks) == 0 {
+		fmt.Printf("No results found for '%s' in %s\n", query, client.BaseURL)
+		return nil
+	}
+	if len(snippets) > 0 {
+		fmt.Println("\n📄 Snippets:")
+		for _, m := range snippets {
+			printListEntry(m.name, m.description, m.author, m.source)
+		}
+	}
+	if len(stacks) > 0 {
+		fmt.Println("\n📦 Stacks:")
+		for _, m := range stacks {
+			printListEntry(m.name, m.description, m.author, m.source)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+var (
+	registryAddr   string
+	registryStore  string
+	registryConfig string
+)
+
+func init() {
+	registryCmd.AddCommand(registryServeCmd)
+	registryServeCmd.Flags().StringVar(&registryAddr, "addr", ":7070", "Address to listen on")
+	registryServeCmd.Flags().StringVar(&registryStore, "store", "", "Store directory to serve (default: global store)")
+	registryServeCmd.Flags().StringVar(&registryConfig, "config", "", "Server config with tokens (default: ~/.boiler/boiler.registry.json)")
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/models"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the store index from files on disk",
+	Long: `Rebuild boiler.meta.json from the snippet and stack files in your stores.
+
+The project-local store, if there is one, and the global store are
+reindexed. Use --local or --global to reindex only one of them.
+
+Every file under the snippets directory is indexed using the metadata
+comments in the file (__author, __desc). Every directory under the stacks
+directory is indexed using its boiler.stack.json.
+
+Names and versions come from the file name (logger@2.js) or the stack
+config (id and version). Files without a version are indexed as version 1.
+Creation times, notes, signatures and digests of entries that are already
+indexed are kept. Files that no longer match their recorded digests are
+reported and the command fails, so 'bl add' keeps refusing them; restore
+the files or remove and re-add the resource.
+
+Use this after copying files into the store by hand or when the index is
+damaged beyond what 'bl fsck --fix' can repair.`,
+	Example: `  # Rebuild the index of the local and global stores
+  bl reindex
+
+  # Rebuild only the project-local store
+  bl reindex --local`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger.Info("Reindexing store")
+
+		if err := reindexStore(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func reindexStore() error {
+	scopes, err := readScopes(reindexLocal, reindexGlobal)
+	if err != nil {
+		return err
+	}
+
+	var changed []error
+	for _, sc := range scopes {
+		st, err := sc.load()
+		if err != nil {
+			return err
+		}
+
+		snippets, stacks, c, err := reindex(st, sc.Snippets, sc.Stacks)
+		if err != nil {
+			return err
+		}
+
+		if len(scopes) > 1 {
+			fmt.Printf("✓ Reindexed %d snippets and %d stacks in the %s store\n", snippets, stacks, sc.Name)
+		} else {
+			fmt.Printf("✓ Reindexed %d snippets and %d stacks\n", snippets, stacks)
+		}
+		logger.Info(fmt.Sprintf("Store %s reindexed: %d snippets, %d stacks", sc.Root, snippets, stacks))
+		changed = append(changed, c...)
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, err := range changed {
+		fmt.Printf("  ✗ %v\n", err)
+	}
+	return fmt.Errorf("%d resource(s) changed since they were stored; their recorded digests were kept", len(changed))
+}
+
+// reindex replaces the index of st with entries for the snippet files and
+// stack directories on disk and returns how many of each it indexed.
+// Entries that were already indexed keep their digests, so a file changed
+// by hand is not taken as correct; those that no longer match are returned
+// as *store.IntegrityError.
+func reindex(st *store.Store, snippetsDir, stacksDir string) (int, int, []error, error) {
+	// Remember existing entries by path so what only the index records survives
+	oldSnippets := make(map[string]*store.SnippetEntry)
+	for _, entry := range st.SnippetEntries() {
+		oldSnippets[filepath.Clean(entry.Path)] = entry
+	}
+	oldStacks := make(map[string]*store.StackEntry)
+	for _, entry := range st.StackEntries() {
+		oldStacks[filepath.Clean(entry.Path)] = entry
+	}
+
+	files, err := store.ScanSnippetFiles(snippetsDir)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	seen := make(map[string]string)
+	var changed []error
+	var snippets []*store.SnippetEntry
+	for _, path := range files {
+		entry, err := indexSnippetFile(path)
+		if err != nil {
+			fmt.Printf("⚠ Skipping %s: %v\n", path, err)
+			continue
+		}
+		if other, ok := seen[entry.FullName()]; ok {
+			fmt.Printf("⚠ Skipping %s: '%s' is already indexed from %s\n", path, entry.FullName(), other)
+			continue
+		}
+		if old, ok := oldSnippets[filepath.Clean(path)]; ok {
+			if !old.CreatedAt.IsZero() {
+				entry.CreatedAt = old.CreatedAt
+			}
+			entry.Message = old.Message
+			entry.Signature = old.Signature
+			if old.Checksum != "" {
+				entry.Checksum = old.Checksum
+				if err := store.VerifySnippet(entry); err != nil {
+					changed = append(changed, err)
+				}
+			}
+		}
+		seen[entry.FullName()] = path
+		snippets = append(snippets, entry)
+	}
+
+	dirs, err := store.ScanStackDirs(stacksDir)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	var stacks []*store.StackEntry
+	for _, path := range dirs {
+		entry, err := indexStackDir(path)
+		if err != nil {
+			fmt.Printf("⚠ Skipping %s: %v\n", path, err)
+			continue
+		}
+		if other, ok := seen[entry.FullName()]; ok {
+			fmt.Printf("⚠ Skipping %s: '%s' is already indexed from %s\n", path, entry.FullName(), other)
+			continue
+		}
+		if old, ok := oldStacks[filepath.Clean(path)]; ok {
+			if !old.CreatedAt.IsZero() {
+				entry.CreatedAt = old.CreatedAt
+			}
+			entry.Message = old.Message
+			entry.Signature = old.Signature
+			if old.Checksum != "" || len(old.Files) > 0 {
+				entry.Checksum = old.Checksum
+				entry.Files = old.Files
+				if err := store.VerifyStack(entry); err != nil {
+					changed = append(changed, err)
+				}
+			}
+		}
+		seen[entry.FullName()] = path
+		stacks = append(stacks, entry)
+	}
+
+	if err := st.Replace(snippets, stacks); err != nil {
+		return 0, 0, nil, fmt.Errorf("failed to update metadata: %w", err)
+	}
+	return len(snippets), len(stacks), changed, nil
+}
+
+// indexSnippetFile builds a store entry for a snippet file found on disk
+func indexSnippetFile(path string) (*store.SnippetEntry, error) {
+	name, version, ext := store.ParseResourceName(filepath.Base(path))
+	if ext == "" {
+		return nil, fmt.Errorf(utils.ErrSnippetNeedExt)
+	}
+	if version == "" {
+		version = "1"
+	}
+
+	entry, err := store.NewSnippetEntry(store.FormatResourceName(name, version, ext), path)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := utils.ParseSnippetMetadata(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse snippet metadata: %w", err)
+	}
+	entry.Author = meta.Author
+	entry.Description = meta.Description
+
+	if info, err := os.Stat(path); err == nil {
+		entry.CreatedAt = info.ModTime()
+		entry.UpdatedAt = info.ModTime()
+	}
+	return entry, nil
+}
+
+// indexStackDir builds a store entry for a stack directory found on disk.
+// The id and version come from boiler.stack.json, falling back to the
+// directory name when the config is missing.
+func indexStackDir(path string) (*store.StackEntry, error) {
+	name, version, _ := strings.Cut(filepath.Base(path), "@")
+	var config *models.StackConfig
+	if c, err := models.ParseStackConfig(path); err == nil {
+		config = c
+		if c.ID != "" {
+			name = c.ID
+		}
+		if c.Version != "" {
+			version = c.Version
+		}
+	}
+	if version == "" {
+		version = "1"
+	}
+
+	entry, err := store.NewStackEntry(store.FormatResourceName(name, version, ""), path)
+	if err != nil {
+		return nil, err
+	}
+
+	if config != nil {
+		entry.Author = config.Author
+		entry.Description = config.Description
+		entry.CreatedAt = config.CreatedAt
+	}
+	if info, err := os.Stat(path); err == nil {
+		entry.UpdatedAt = info.ModTime()
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = info.ModTime()
+		}
+	}
+	return entry, nil
+}
+
+var (
+	reindexLocal  bool
+	reindexGlobal bool
+)
+
+func init() {
+	reindexCmd.Flags().BoolVarP(&reindexLocal, FlagLocal, FlagLocalShort, false, "Only reindex the project-local store")
+	reindexCmd.Flags().BoolVarP(&reindexGlobal, FlagGlobal, FlagGlobalShort, false, "Only reindex the global store")
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+)
+
+func TestReindexKeepsNotesAndSignatures(t *testing.T) {
+	root := t.TempDir()
+	snippetsDir := filepath.Join(root, "snippets")
+	stacksDir := filepath.Join(root, "stacks")
+
+	snippetPath := filepath.Join(snippetsDir, "js", "logger@1.0.0.js")
+	stackPath := filepath.Join(stacksDir, "api@1.0.0")
+	for path, content := range map[string]string{
+		snippetPath: "// __author Jane\nconsole.log('hi')\n",
+		filepath.Join(stackPath, "boiler.stack.json"): `{"id": "api", "version": "1.0.0"}`,
+		filepath.Join(stackPath, "main.go"):           "package main\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := store.NewStore(root)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	sig := &signing.Signature{KeyID: "key1", PublicKey: "pub", Value: "sig"}
+	snippet, err := store.NewSnippetEntry("logger@1.0.0.js", snippetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snippet.Message = "first release"
+	snippet.Signature = sig
+	if err := st.AddSnippet(snippet); err != nil {
+		t.Fatal(err)
+	}
+	stack, err := store.NewStackEntry("api@1.0.0", stackPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stack.Message = "initial layout"
+	stack.Signature = sig
+	if err := st.AddStack(stack); err != nil {
+		t.Fatal(err)
+	}
+
+	snippets, stacks, changed, err := reindex(st, snippetsDir, stacksDir)
+	if err != nil {
+		t.Fatalf("reindex() error = %v", err)
+	}
+	if len(changed) > 0 {
+		t.Fatalf("reindex() reported changes %v", changed)
+	}
+	if snippets != 1 || stacks != 1 {
+		t.Fatalf("reindexed %d snippets and %d stacks, want 1 and 1", snippets, stacks)
+	}
+
+	// Read the index back from disk as the next bl command would
+	st = store.NewStore(root)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	gotSnippet, ok := st.GetSnippet("logger@1.0.0.js")
+	if !ok {
+		t.Fatal("snippet is missing after reindex")
+	}
+	if gotSnippet.Message != "first release" {
+		t.Errorf("snippet message = %q, want %q", gotSnippet.Message, "first release")
+	}
+	if gotSnippet.Signature == nil || *gotSnippet.Signature != *sig {
+		t.Errorf("snippet signature = %+v, want %+v", gotSnippet.Signature, sig)
+	}
+	if gotSnippet.Author != "Jane" {
+		t.Errorf("snippet author = %q, want %q", gotSnippet.Author, "Jane")
+	}
+
+	gotStack, ok := st.GetStack("api@1.0.0")
+	if !ok {
+		t.Fatal("stack is missing after reindex")
+	}
+	if gotStack.Message != "initial layout" {
+		t.Errorf("stack message = %q, want %q", gotStack.Message, "initial layout")
+	}
+	if gotStack.Signature == nil || *gotStack.Signature != *sig {
+		t.Errorf("stack signature = %+v, want %+v", gotStack.Signature, sig)
+	}
+}
+
+func TestReindexKeepsDigestsOfChangedFiles(t *testing.T) {
+	root := t.TempDir()
+	snippetsDir := filepath.Join(root, "snippets")
+	snippetPath := filepath.Join(snippetsDir, "js", "logger@1.0.0.js")
+	if err := os.MkdirAll(filepath.Dir(snippetPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(snippetPath, []byte("console.log('hi')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st := store.NewStore(root)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := store.NewSnippetEntry("logger@1.0.0.js", snippetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddSnippet(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Edit the stored file by hand
+	if err := os.WriteFile(snippetPath, []byte("console.log('tampered')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, changed, err := reindex(st, snippetsDir, filepath.Join(root, "stacks"))
+	if err != nil {
+		t.Fatalf("reindex() error = %v", err)
+	}
+	if len(changed) != 1 {
+		t.Fatalf("reindex() reported %d changes, want 1", len(changed))
+	}
+	var ierr *store.IntegrityError
+	if !errors.As(changed[0], &ierr) || ierr.Name != "logger@1.0.0.js" {
+		t.Fatalf("reindex() reported %v, want an integrity error for logger@1.0.0.js", changed[0])
+	}
+
+	got, ok := st.GetSnippet("logger@1.0.0.js")
+	if !ok {
+		t.Fatal("snippet is missing after reindex")
+	}
+	if got.Checksum != entry.Checksum {
+		t.Errorf("checksum = %s, want the recorded %s", got.Checksum, entry.Checksum)
+	}
+	if err := store.VerifySnippet(got); err == nil {
+		t.Error("VerifySnippet() passes the edited file after reindex")
+	}
+}
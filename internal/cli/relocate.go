@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/config"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var storeRelocateCmd = &cobra.Command{
+	Use:   "relocate <newdir>",
+	Short: "Move the whole store to a new directory",
+	Long: `Move your store, including all snippets, stacks and boiler.meta.json,
+to a new directory and update the configuration to point at it.
+
+Paths in boiler.meta.json are relative to the store, so nothing inside the
+store has to be rewritten. Snippet and stack directories configured inside
+the store move along with it.
+
+The destination must not exist or must be an empty directory.`,
+	Example: `  # Move the store into a synced folder
+  bl store relocate ~/Dropbox/boiler-store`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		newDir := config.ExpandPath(args[0])
+		logger.Info(fmt.Sprintf("Relocating store to: %s", newDir))
+
+		if err := relocateStore(newDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func relocateStore(newDir string) error {
+	newDir, err := filepath.Abs(newDir)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	st, err := utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		return err
+	}
+
+	oldDir := st.Root()
+	if err := st.Relocate(newDir); err != nil {
+		return err
+	}
+
+	// Update config; move the store back if that fails so config and
+	// store never disagree
+	oldPaths := cfg.Paths
+	cfg.Paths.Store = newDir
+	cfg.Paths.Snippets = movedPath(cfg.Paths.Snippets, oldDir, newDir)
+	cfg.Paths.Stacks = movedPath(cfg.Paths.Stacks, oldDir, newDir)
+	if err := config.Save(cfg); err != nil {
+		cfg.Paths = oldPaths
+		if rbErr := st.Relocate(oldDir); rbErr != nil {
+			return fmt.Errorf("failed to save config: %w (store left at %s: %v)", err, newDir, rbErr)
+		}
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("✓ Moved store from %s to %s\n", oldDir, newDir)
+	logger.Info(fmt.Sprintf("Store relocated: %s -> %s", oldDir, newDir))
+	return nil
+}
+
+// movedPath returns where path ends up when oldDir is moved to newDir
+func movedPath(path, oldDir, newDir string) string {
+	rel, err := filepath.Rel(oldDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.Join(newDir, rel)
+}
+
+func init() {
+	storeCmd.AddCommand(storeRelocateCmd)
+}
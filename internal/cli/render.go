@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+// renderedFile is a file as 'bl add' writes it into a project
+type renderedFile struct {
+	data []byte
+	mode os.FileMode
+}
+
+// renderLocked renders the stored version fullName of a lock file entry's
+// resource with values, the way 'bl add' would write it. Files are keyed by
+// their path in the lock file.
+func renderLocked(st *store.Store, e lockfile.Entry, fullName string, values map[string]string) (map[string]renderedFile, error) {
+	files := make(map[string]renderedFile)
+
+	if e.Kind != lockfile.KindStack {
+		entry, ok := st.GetSnippet(fullName)
+		if !ok {
+			return nil, fmt.Errorf(utils.ErrResourceNotFound, "snippet", fullName)
+		}
+		info, err := os.Stat(entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf(utils.ErrResourceNotFound, "snippet file", entry.Path)
+		}
+		data, err := utils.RenderFileWithVariables(entry.Path, values)
+		if err != nil {
+			return nil, err
+		}
+		files[e.Path] = renderedFile{data: data, mode: info.Mode().Perm()}
+		return files, nil
+	}
+
+	entry, ok := st.GetStack(fullName)
+	if !ok {
+		return nil, fmt.Errorf(utils.ErrResourceNotFound, "stack", fullName)
+	}
+	src, err := stackFiles(entry.Path, stackIgnorePatterns)
+	if err != nil {
+		return nil, err
+	}
+	for rel, p := range src {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		files[path.Join(e.Path, rel)] = renderedFile{data: data, mode: info.Mode().Perm()}
+	}
+	return files, nil
+}
+
+// sortedPaths returns the paths of one or more rendered file sets, sorted
+// and without duplicates
+func sortedPaths(sets ...map[string]renderedFile) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, set := range sets {
+		for p := range set {
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
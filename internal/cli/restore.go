@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [name]",
+	Short: "Bring a removed snippet or stack back from the trash",
+	Long: `Restore a snippet or stack removed with 'bl clean' into the store it was
+removed from.
+
+The name can be the full name (logger@1.0.0.js), the name without version
+(logger.js) if only one version of it is in the trash, or the ID shown by
+'bl trash ls'. If the same version was removed more than once, the most
+recently removed copy is restored.
+
+Tags that pointed at the version are restored too, unless they were moved
+to another version in the meantime.`,
+	Example: `  # Restore a snippet version
+  bl restore logger@1.0.0.js
+
+  # Restore a stack
+  bl restore express@1.0.0
+
+  # Restore everything in the trash
+  bl restore --all`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch {
+		case restoreAll:
+			err = restoreAllItems()
+		case len(args) == 1:
+			err = restoreItem(args[0])
+		default:
+			err = fmt.Errorf("name required, or use --all")
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func restoreItem(query string) error {
+	trash := openTrash()
+	items, err := trash.Find(query)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf(utils.ErrResourceNotFound, "trash item", query)
+	}
+
+	// Several matches are only fine when they are copies of one version
+	for _, item := range items[1:] {
+		if item.Name != items[0].Name {
+			var names []string
+			for _, item := range items {
+				names = append(names, item.Name)
+			}
+			return fmt.Errorf("'%s' matches several items: %s", query, strings.Join(names, ", "))
+		}
+	}
+
+	st, err := itemStore(items[0], map[string]*store.Store{})
+	if err != nil {
+		return err
+	}
+	return restoreTrashItem(st, trash, items[0])
+}
+
+func restoreAllItems() error {
+	trash := openTrash()
+	items, err := trash.List()
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println(utils.MsgTrashEmpty)
+		return nil
+	}
+
+	// Most recent first, so older copies of the same version are skipped
+	// and stay in the trash
+	stores := make(map[string]*store.Store)
+	seen := make(map[string]bool)
+	failed := 0
+	for _, item := range items {
+		st, err := itemStore(item, stores)
+		if err != nil {
+			return err
+		}
+
+		key := st.Root() + "\x00" + item.Name
+		if seen[key] {
+			fmt.Printf("• Kept older copy of '%s' in trash [%s]\n", item.Name, item.ID)
+			continue
+		}
+		seen[key] = true
+
+		if err := restoreTrashItem(st, trash, item); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Skipped '%s': %v\n", item.Name, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d item(s) could not be restored", failed)
+	}
+	return nil
+}
+
+// itemStore returns the store an item was removed from, loading each store
+// once. Items trashed before the store was recorded, or whose store is gone,
+// e.g. after 'bl store relocate', go back to the global store.
+func itemStore(item *store.TrashItem, stores map[string]*store.Store) (*store.Store, error) {
+	root := cfg.Paths.Store
+	if item.Store != "" {
+		if _, err := os.Stat(filepath.Join(item.Store, store.MetaFile)); err == nil {
+			root = item.Store
+		}
+	}
+
+	if st, ok := stores[root]; ok {
+		return st, nil
+	}
+	st, err := utils.LoadStore(root)
+	if err != nil {
+		return nil, err
+	}
+	stores[root] = st
+	return st, nil
+}
+
+func restoreTrashItem(st *store.Store, trash *store.Trash, item *store.TrashItem) error {
+	if err := st.Restore(trash, item); err != nil {
+		return err
+	}
+
+	if item.Kind == store.TrashStack {
+		fmt.Printf(utils.MsgStackRestored, item.Name)
+	} else {
+		fmt.Printf(utils.MsgSnippetRestored, item.Name)
+	}
+	logger.Info(fmt.Sprintf("Restored from trash: %s", item.Name))
+	commitStore(st.Root(), "Restore "+item.Name)
+	return nil
+}
+
+var restoreAll bool
+
+func init() {
+	restoreCmd.Flags().BoolVarP(&restoreAll, FlagAll, FlagAllShort, false, "Restore everything in the trash")
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+func TestRestoreAllIntoSourceStore(t *testing.T) {
+	project := useTestConfig(t)
+	root, err := store.InitLocal(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := utils.LoadStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove the same version twice, so the trash holds an older copy
+	path := filepath.Join(root, "snippets", "js", "logger@1.0.0.js")
+	for _, content := range []string{"console.log(1)\n", "console.log(2)\n"} {
+		writeFiles(t, root, map[string]string{"snippets/js/logger@1.0.0.js": content})
+		entry, err := store.NewSnippetEntry("logger@1.0.0.js", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := local.AddSnippet(entry); err != nil {
+			t.Fatal(err)
+		}
+		if err := removeResources(local, []string{"logger@1.0.0.js"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := restoreAllItems(); err != nil {
+		t.Fatalf("restoreAllItems() error = %v", err)
+	}
+
+	local, err = utils.LoadStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !local.SnippetExists("logger@1.0.0.js") {
+		t.Error("snippet was not restored into the local store")
+	}
+	if err := store.VerifySnippet(mustSnippet(t, local, "logger@1.0.0.js")); err != nil {
+		t.Errorf("restored snippet does not match its entry: %v", err)
+	}
+	global, err := utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if global.SnippetExists("logger@1.0.0.js") {
+		t.Error("snippet was restored into the global store")
+	}
+
+	items, err := openTrash().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Store != root {
+		t.Fatalf("trash holds %+v, want the older copy from %s", items, root)
+	}
+}
+
+func mustSnippet(t *testing.T, st *store.Store, name string) *store.SnippetEntry {
+	t.Helper()
+	e, ok := st.GetSnippet(name)
+	if !ok {
+		t.Fatalf("snippet %s not found", name)
+	}
+	return e
+}
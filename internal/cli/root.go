@@ -73,4 +73,20 @@ func init() {
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(pathCmd)
 	rootCmd.AddCommand(selfCmd)
+	rootCmd.AddCommand(fsckCmd)
+	rootCmd.AddCommand(reindexCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(registryCmd)
+	rootCmd.AddCommand(publishCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(keyCmd)
+	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(statusCmd)
 }
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+// Store scope names
+const (
+	scopeLocal  = "local"
+	scopeGlobal = "global"
+)
+
+// storeScope is one store bl can work on: the global store from the config
+// or a project-local .boiler/ store
+type storeScope struct {
+	Name     string
+	Root     string
+	Snippets string
+	Stacks   string
+}
+
+func globalScope() storeScope {
+	return storeScope{
+		Name:     scopeGlobal,
+		Root:     cfg.Paths.Store,
+		Snippets: cfg.Paths.Snippets,
+		Stacks:   cfg.Paths.Stacks,
+	}
+}
+
+func localScopeAt(root string) storeScope {
+	return storeScope{
+		Name:     scopeLocal,
+		Root:     root,
+		Snippets: filepath.Join(root, "snippets"),
+		Stacks:   filepath.Join(root, "stacks"),
+	}
+}
+
+// findLocalScope looks for a project-local store from the working directory
+// up. The global Boiler directories never count as a local store.
+func findLocalScope() (storeScope, bool) {
+	root, ok := store.FindLocal(".", cfg.Paths.Root, cfg.Paths.Store)
+	if !ok {
+		return storeScope{}, false
+	}
+	return localScopeAt(root), true
+}
+
+// readScopes returns the stores to look resources up in, local first.
+// Without flags, or with both, that is the local store if there is one
+// followed by the global store.
+func readScopes(local, global bool) ([]storeScope, error) {
+	localScope, found := findLocalScope()
+
+	switch {
+	case local && !global:
+		if !found {
+			return nil, fmt.Errorf("no local store found. Run 'bl store --local' in your project to create one")
+		}
+		return []storeScope{localScope}, nil
+	case global && !local:
+		return []storeScope{globalScope()}, nil
+	}
+
+	if found {
+		return []storeScope{localScope, globalScope()}, nil
+	}
+	return []storeScope{globalScope()}, nil
+}
+
+// writeScopes returns the stores to store a resource in. The global store
+// is the default; a local store is created in the working directory if
+// none is found.
+func writeScopes(local, both bool) ([]storeScope, error) {
+	if !local && !both {
+		return []storeScope{globalScope()}, nil
+	}
+
+	localScope, found := findLocalScope()
+	if !found {
+		root, err := store.InitLocal(".")
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("✓ Created local store at %s\n", root)
+		localScope = localScopeAt(root)
+	}
+
+	if both {
+		return []storeScope{localScope, globalScope()}, nil
+	}
+	return []storeScope{localScope}, nil
+}
+
+func (sc storeScope) load() (*store.Store, error) {
+	return utils.LoadStore(sc.Root)
+}
+
+// scopedStore is a loaded store together with where it came from
+type scopedStore struct {
+	store *store.Store
+	scope storeScope
+}
+
+// source returns the label shown next to entries, which is only needed
+// when entries from more than one store are mixed
+func (s scopedStore) source(stores int) string {
+	if stores < 2 {
+		return ""
+	}
+	return s.scope.Name
+}
+
+func loadScopes(scopes []storeScope) ([]scopedStore, error) {
+	stores := make([]scopedStore, 0, len(scopes))
+	for _, sc := range scopes {
+		st, err := sc.load()
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, scopedStore{store: st, scope: sc})
+	}
+	return stores, nil
+}
+
+// lookupStore returns the first store, local before global, that holds any
+// version of the named resource. Without extension, stacks and snippets in
+// any language count.
+func lookupStore(scopes []storeScope, baseName, ext string) (*store.Store, storeScope, error) {
+	for _, sc := range scopes {
+		st, err := sc.load()
+		if err != nil {
+			return nil, sc, err
+		}
+		if hasResource(st, baseName, ext) {
+			return st, sc, nil
+		}
+	}
+
+	// Fall back to the last store so callers report "not found" as before
+	last := scopes[len(scopes)-1]
+	st, err := last.load()
+	return st, last, err
+}
+
+func hasResource(st *store.Store, baseName, ext string) bool {
+	if ext != "" {
+		return len(st.GetAllVersions(baseName, ext)) > 0
+	}
+	return len(st.GetAllStackVersions(baseName)) > 0 || len(findMatchingSnippets(st, baseName)) > 0
+}
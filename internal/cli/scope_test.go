@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+func TestFindLocalScope(t *testing.T) {
+	project := useTestConfig(t)
+
+	if sc, ok := findLocalScope(); ok {
+		t.Fatalf("findLocalScope() = %+v, want none before a local store exists", sc)
+	}
+
+	root, err := store.InitLocal(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deep := filepath.Join(project, "src", "deep")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(deep)
+
+	sc, ok := findLocalScope()
+	if !ok {
+		t.Fatal("findLocalScope() found nothing from a subdirectory")
+	}
+	if sc.Name != scopeLocal || sc.Root != root {
+		t.Errorf("findLocalScope() = %+v, want the local store at %s", sc, root)
+	}
+	if sc.Snippets != filepath.Join(root, "snippets") || sc.Stacks != filepath.Join(root, "stacks") {
+		t.Errorf("findLocalScope() = %+v, want snippets and stacks below %s", sc, root)
+	}
+}
+
+func TestFindLocalScopeSkipsGlobalRoot(t *testing.T) {
+	useTestConfig(t)
+
+	// The global Boiler directory is also called .boiler; it must not be
+	// taken for a project store from the directory that holds it
+	writeFiles(t, cfg.Paths.Root, map[string]string{store.MetaFile: "{}"})
+	t.Chdir(filepath.Dir(cfg.Paths.Root))
+
+	if sc, ok := findLocalScope(); ok {
+		t.Errorf("findLocalScope() = %+v, want the global root skipped", sc)
+	}
+}
+
+type scopeCase struct {
+	name          string
+	local, global bool
+	want          []string
+	wantErr       bool
+}
+
+func TestReadScopes(t *testing.T) {
+	project := useTestConfig(t)
+
+	tests := []scopeCase{
+		{name: "default", want: []string{scopeGlobal}},
+		{name: "local", local: true, wantErr: true},
+		{name: "global", global: true, want: []string{scopeGlobal}},
+		{name: "both", local: true, global: true, want: []string{scopeGlobal}},
+	}
+	for _, tt := range tests {
+		t.Run("without local store/"+tt.name, func(t *testing.T) {
+			checkScopes(t, tt.local, tt.global, tt.want, tt.wantErr)
+		})
+	}
+
+	if _, err := store.InitLocal(project); err != nil {
+		t.Fatal(err)
+	}
+	tests = []scopeCase{
+		{name: "default", want: []string{scopeLocal, scopeGlobal}},
+		{name: "local", local: true, want: []string{scopeLocal}},
+		{name: "global", global: true, want: []string{scopeGlobal}},
+		{name: "both", local: true, global: true, want: []string{scopeLocal, scopeGlobal}},
+	}
+	for _, tt := range tests {
+		t.Run("with local store/"+tt.name, func(t *testing.T) {
+			checkScopes(t, tt.local, tt.global, tt.want, tt.wantErr)
+		})
+	}
+}
+
+func checkScopes(t *testing.T, local, global bool, want []string, wantErr bool) {
+	t.Helper()
+	scopes, err := readScopes(local, global)
+	if wantErr {
+		if err == nil {
+			t.Fatalf("readScopes(%v, %v) = %+v, want an error", local, global, scopes)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("readScopes(%v, %v) error = %v", local, global, err)
+	}
+	if got := scopeNames(scopes); !equalStrings(got, want) {
+		t.Errorf("readScopes(%v, %v) = %q, want %q", local, global, got, want)
+	}
+}
+
+func TestWriteScopes(t *testing.T) {
+	project := useTestConfig(t)
+	localRoot := filepath.Join(project, store.LocalDir)
+
+	scopes, err := writeScopes(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := scopeNames(scopes); !equalStrings(got, []string{scopeGlobal}) {
+		t.Errorf("writeScopes() = %q, want only the global store", got)
+	}
+	if _, err := os.Stat(localRoot); !os.IsNotExist(err) {
+		t.Errorf("writeScopes() created a local store without --local")
+	}
+
+	scopes, err = writeScopes(true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scopes) != 1 || scopes[0].Name != scopeLocal || scopes[0].Root != localRoot {
+		t.Fatalf("writeScopes(--local) = %+v, want a new local store at %s", scopes, localRoot)
+	}
+	if _, ok := findLocalScope(); !ok {
+		t.Error("writeScopes(--local) did not create a local store")
+	}
+
+	scopes, err = writeScopes(false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := scopeNames(scopes); !equalStrings(got, []string{scopeLocal, scopeGlobal}) {
+		t.Errorf("writeScopes(--both) = %q, want local then global", got)
+	}
+	if scopes[0].Root != localRoot {
+		t.Errorf("writeScopes(--both) local root = %s, want the existing %s", scopes[0].Root, localRoot)
+	}
+}
+
+func TestLookupStorePrefersLocal(t *testing.T) {
+	project := useTestConfig(t)
+	root, err := store.InitLocal(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scopes, err := readScopes(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addTestSnippet(t, cfg.Paths.Store, "logger@1.0.0.js")
+	if _, sc, err := lookupStore(scopes, "logger", ".js"); err != nil || sc.Name != scopeGlobal {
+		t.Errorf("lookupStore() = %s, %v; want the global store that holds logger", sc.Name, err)
+	}
+
+	addTestSnippet(t, root, "logger@2.0.0.js")
+	if _, sc, err := lookupStore(scopes, "logger", ".js"); err != nil || sc.Name != scopeLocal {
+		t.Errorf("lookupStore() = %s, %v; want the local store first", sc.Name, err)
+	}
+	if _, sc, err := lookupStore(scopes, "logger", ""); err != nil || sc.Name != scopeLocal {
+		t.Errorf("lookupStore() without extension = %s, %v; want the local store", sc.Name, err)
+	}
+
+	if _, sc, err := lookupStore(scopes, "missing", ".js"); err != nil || sc.Name != scopeGlobal {
+		t.Errorf("lookupStore() for a missing resource = %s, %v; want the last store", sc.Name, err)
+	}
+}
+
+// addTestSnippet writes a snippet into the store at root and indexes it
+func addTestSnippet(t *testing.T, root, name string) {
+	t.Helper()
+	rel := "snippets/js/" + name
+	writeFiles(t, root, map[string]string{rel: "console.log(1)\n"})
+	st, err := utils.LoadStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := store.NewSnippetEntry(name, filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddSnippet(entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func scopeNames(scopes []storeScope) []string {
+	names := make([]string, len(scopes))
+	for i, sc := range scopes {
+		names[i] = sc.Name
+	}
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
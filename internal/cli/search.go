@@ -5,20 +5,22 @@ import (
 	"os"
 	"strings"
 
-	"github.com/rishiyaduwanshi/boiler/internal/utils"
 	"github.com/spf13/cobra"
 )
 
 var searchCmd = &cobra.Command{
 	Use:   "search [query]",
 	Short: "Search for snippets or stacks",
-	Long: `Search for resources in your store by name.
+	Long: `Search for resources in your store by name, description or author.
 
 Searches both snippets and stacks by default. Use flags to filter:
   - Use -s or --snippets to search only snippets
   - Use -k or --stacks to search only stacks
 
-Search is case-insensitive and matches partial names.`,
+Search is case-insensitive and matches partial text. A project-local
+.boiler/ store is searched too, with results marked [local] or [global].
+
+With --remote, the registry in boiler.conf.json is searched instead.`,
 	Example: `  # Search for anything with 'error'
   bl search error
 
@@ -26,7 +28,10 @@ Search is case-insensitive and matches partial names.`,
   bl search logger --snippets
 
   # Search only stacks
-  bl search express --stacks`,
+  bl search express --stacks
+
+  # Search the registry
+  bl search logger --remote`,
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		query := args[0]
@@ -40,7 +45,15 @@ Search is case-insensitive and matches partial names.`,
 }
 
 func searchResources(query string) error {
-	st, err := utils.LoadStore(cfg.Paths.Store)
+	if searchRemote {
+		return searchRegistry(query)
+	}
+
+	scopes, err := readScopes(false, false)
+	if err != nil {
+		return err
+	}
+	stores, err := loadScopes(scopes)
 	if err != nil {
 		return err
 	}
@@ -50,38 +63,40 @@ func searchResources(query string) error {
 
 	// Search snippets
 	if !searchStacks {
-		snippets := st.ListSnippets()
-		matches := []string{}
-		for _, name := range snippets {
-			if strings.Contains(strings.ToLower(name), query) {
-				matches = append(matches, name)
+		matches := []listEntry{}
+		for _, sst := range stores {
+			for _, entry := range sst.store.SnippetEntries() {
+				if matchesQuery(query, entry.FullName(), entry.Description, entry.Author) {
+					matches = append(matches, listEntry{entry.FullName(), entry.Description, entry.Author, sst.source(len(stores))})
+				}
 			}
 		}
 
 		if len(matches) > 0 {
 			foundAny = true
 			fmt.Println("\n📄 Snippets:")
-			for _, name := range matches {
-				fmt.Printf("  • %s\n", name)
+			for _, m := range matches {
+				printListEntry(m.name, m.description, m.author, m.source)
 			}
 		}
 	}
 
 	// Search stacks
 	if !searchSnippets {
-		stacks := st.ListStacks()
-		matches := []string{}
-		for _, name := range stacks {
-			if strings.Contains(strings.ToLower(name), query) {
-				matches = append(matches, name)
+		matches := []listEntry{}
+		for _, sst := range stores {
+			for _, entry := range sst.store.StackEntries() {
+				if matchesQuery(query, entry.FullName(), entry.Description, entry.Author) {
+					matches = append(matches, listEntry{entry.FullName(), entry.Description, entry.Author, sst.source(len(stores))})
+				}
 			}
 		}
 
 		if len(matches) > 0 {
 			foundAny = true
 			fmt.Println("\n📦 Stacks:")
-			for _, name := range matches {
-				fmt.Printf("  • %s\n", name)
+			for _, m := range matches {
+				printListEntry(m.name, m.description, m.author, m.source)
 			}
 		}
 	}
@@ -95,6 +110,16 @@ func searchResources(query string) error {
 	return nil
 }
 
+// matchesQuery reports whether any of the fields contains the lowercase query
+func matchesQuery(query string, fields ...string) bool {
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
 var (
 	searchSnippets bool
 	searchStacks   bool
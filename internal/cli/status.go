@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [name...]",
+	Short: "Show which added files were changed in the project",
+	Long: `Compare the files of every resource in boiler.lock.json with what 'bl add'
+wrote. Each resource is rendered again at its recorded version and with its
+recorded variable values, and every file is reported as:
+
+  unchanged  identical to the render
+  modified   edited in the project
+  deleted    no longer in the project
+
+--diff shows the changes as a unified diff from the render to the project.
+Names limit the report to some resources, by resource name or path, like
+'bl update'.`,
+	Example: `  # What did we change from the templates?
+  bl status
+
+  # Show the changes to one stack
+  bl status express --diff`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runStatus(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var statusDiff bool
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusDiff, "diff", false, "Show the changes as a unified diff")
+}
+
+// File states reported by bl status
+const (
+	statusUnchanged = "unchanged"
+	statusModified  = "modified"
+	statusDeleted   = "deleted"
+)
+
+func runStatus(names []string) error {
+	lock, err := requireLockfile()
+	if err != nil {
+		return err
+	}
+
+	entries := lock.Resources
+	if len(names) > 0 {
+		entries = nil
+		for _, name := range names {
+			baseName, _, ext := store.ParseResourceName(name)
+			matched := matchLocked(lock, name, baseName, ext)
+			if len(matched) == 0 {
+				return fmt.Errorf("'%s' is not in %s", name, lock.Path())
+			}
+			entries = append(entries, matched...)
+		}
+	}
+	if len(entries) == 0 {
+		fmt.Printf("Nothing added yet, %s is empty\n", lock.Path())
+		return nil
+	}
+
+	scopes, err := readScopes(false, false)
+	if err != nil {
+		return err
+	}
+	stores, err := loadScopes(scopes)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	failed := 0
+	for _, e := range entries {
+		fmt.Printf("%s %s (%s)\n", kindIcon(e.Kind), e.Path, e.FullName())
+
+		st, err := lockedStore(stores, e)
+		if err == nil {
+			err = statusEntry(st, lock, e, counts)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("  ✗ cannot compare: %v\n", err)
+		}
+	}
+
+	fmt.Printf("\n%d unchanged, %d modified, %d deleted\n",
+		counts[statusUnchanged], counts[statusModified], counts[statusDeleted])
+	if failed > 0 {
+		return fmt.Errorf("%d resource(s) could not be compared", failed)
+	}
+	return nil
+}
+
+// statusEntry prints the state of every file of one lock file entry and
+// adds them to counts
+func statusEntry(st *store.Store, lock *lockfile.Lockfile, e lockfile.Entry, counts map[string]int) error {
+	rendered, err := renderLocked(st, e, e.FullName(), e.Variables)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range sortedPaths(rendered) {
+		want := rendered[p].data
+		got, err := os.ReadFile(lock.Abs(p))
+		state := statusUnchanged
+		switch {
+		case os.IsNotExist(err):
+			state, got = statusDeleted, nil
+		case err != nil:
+			return err
+		case !bytes.Equal(got, want):
+			state = statusModified
+		}
+		counts[state]++
+		fmt.Printf("  %-10s %s\n", state, p)
+
+		if statusDiff && state != statusUnchanged {
+			to := "b/" + p
+			if state == statusDeleted {
+				to = "/dev/null"
+			}
+			fmt.Print(diffBytes(want, got, "a/"+p, to))
+		}
+	}
+	return nil
+}
+
+func kindIcon(kind string) string {
+	if kind == lockfile.KindStack {
+		return "📦"
+	}
+	return "📄"
+}
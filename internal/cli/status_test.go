@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+func TestRunStatus(t *testing.T) {
+	project := useTestConfig(t)
+	st, err := utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, cfg.Paths.Store, map[string]string{
+		"snippets/js/logger@1.0.0.js":        "console.log('hi')\n",
+		"stacks/api@1.0.0/app.js":            "listen(3000)\n",
+		"stacks/api@1.0.0/routes.js":         "route()\n",
+		"stacks/api@1.0.0/README.md":         "# api\n",
+		"stacks/api@1.0.0/boiler.stack.json": `{"id": "api", "version": "1.0.0"}`,
+	})
+	snippet, err := store.NewSnippetEntry("logger@1.0.0.js", filepath.Join(cfg.Paths.Snippets, "js", "logger@1.0.0.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddSnippet(snippet); err != nil {
+		t.Fatal(err)
+	}
+	stack, err := store.NewStackEntry("api@1.0.0", filepath.Join(cfg.Paths.Stacks, "api@1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddStack(stack); err != nil {
+		t.Fatal(err)
+	}
+
+	captureStdout(t, func() {
+		if err := addSnippet(st, "logger@1.0.0.js", "logger.js"); err != nil {
+			t.Fatal(err)
+		}
+		if err := addStack(st, "api@1.0.0", "api"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	out := statusOutput(t)
+	for _, line := range []string{"unchanged  logger.js", "unchanged  api/app.js", "unchanged  api/routes.js", "0 modified, 0 deleted"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("status right after add = %q, want %q", out, line)
+		}
+	}
+	if strings.Contains(out, store.StackConfigFile) {
+		t.Errorf("status = %q, want the stack config left out", out)
+	}
+
+	// Edit one file and delete another in the project
+	writeFiles(t, project, map[string]string{"api/app.js": "listen(8080)\n"})
+	if err := os.Remove(filepath.Join(project, "api", "routes.js")); err != nil {
+		t.Fatal(err)
+	}
+
+	out = statusOutput(t)
+	for _, line := range []string{
+		"unchanged  logger.js",
+		"unchanged  api/README.md",
+		"modified   api/app.js",
+		"deleted    api/routes.js",
+		"2 unchanged, 1 modified, 1 deleted",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("status = %q, want %q", out, line)
+		}
+	}
+
+	statusDiff = true
+	t.Cleanup(func() { statusDiff = false })
+	out = statusOutput(t, "api")
+	for _, line := range []string{"-listen(3000)\n+listen(8080)\n", "+++ /dev/null", "-route()"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("status --diff = %q, want %q", out, line)
+		}
+	}
+	if strings.Contains(out, "logger.js") {
+		t.Errorf("status --diff api = %q, want only the api stack", out)
+	}
+}
+
+// statusOutput runs bl status with names and returns what it printed
+func statusOutput(t *testing.T, names ...string) string {
+	t.Helper()
+	var err error
+	out := captureStdout(t, func() {
+		err = runStatus(names)
+	})
+	if err != nil {
+		t.Fatalf("runStatus(%q) error = %v", names, err)
+	}
+	return out
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -23,41 +22,63 @@ var storeCmd = &cobra.Command{
 	Short: "Store a folder/file as snippet or stack",
 	Long: `Store a file as a snippet or directory as a stack in your Boiler store.
 
-Files are stored as snippets with version numbers.
+Files are stored as snippets with semantic versions (MAJOR.MINOR.PATCH).
 Directories must have a boiler.stack.json config file (run 'bl init' first).
 
 Version Management:
   If snippet already exists, you'll be prompted with options:
     (o) Overwrite - Replace the latest version with new content
-    (n) New version - Create a new incremental version
+    (n) New version - Bump the latest version (patch by default, see --bump)
     (c) Cancel - Abort the operation
-  First-time storage automatically creates version 1
+  First-time storage automatically creates version 1.0.0
+  Versions are ordered semantically, so 1.10.0 comes after 1.9.0
 
 Stacks require boiler.stack.json with:
   - id: Stack name
-  - version: Version number
+  - version: Version such as 1.2.0 (1 and 1.2 are stored as 1.0.0 and 1.2.0)
   - ignore: Patterns to exclude
 
-If a stack version already exists, you'll be prompted to overwrite.`,
+If a stack version already exists, you'll be prompted to overwrite.
+
+Each stored version can carry a note on what changed. Pass it with --message
+or type it when asked; 'bl info <name>' shows the notes in the version history.
+
+Local and Global Stores:
+  Resources go to the global store by default. --local stores them in the
+  project-local .boiler/ store instead, found by walking up from the current
+  directory or created here if there is none. Check .boiler/ into git to
+  share it with your team. --both stores into both.
+
+Use 'bl store relocate <newdir>' to move the whole store elsewhere.`,
 	Example: `  # Store current directory as stack
   bl store
 
   # Store specific file as snippet (first version)
   bl store ./utils/logger.js
-  # Output: ✓ Stored snippet 'logger@1.js'
+  # Output: ✓ Stored snippet 'logger@1.0.0.js'
 
   # Store again - prompts for action
   bl store ./utils/logger.js
-  # Prompt: Snippet 'logger' already exists (1 version(s)). Options:
-  #   (o) Overwrite latest version (1)
-  #   (n) Create new version (2)
+  # Prompt: Snippet 'logger.js' already exists (1 version(s)). Options:
+  #   (o) Overwrite latest version (1.0.0)
+  #   (n) Create new version (1.0.1)
   #   (c) Cancel
 
+  # Store a new minor version
+  bl store ./utils/logger.js --bump minor
+  # (n) creates 'logger@1.1.0.js'
+
+  # Record why the version changed
+  bl store ./utils/logger.js -m "Add JSON output"
+
   # Store directory as stack
   bl store ./my-template
 
   # Store with custom name
-  bl store ./config.js --name dbConfig.js`,
+  bl store ./config.js --name dbConfig.js
+
+  # Share a snippet with the team through the project's .boiler/ store
+  bl store ./utils/logger.js --local`,
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		path := "."
@@ -102,18 +123,30 @@ func storeResource(path string) error {
 		}
 	}
 
-	st := store.NewStore(cfg.Paths.Store)
-	if err := st.Load(); err != nil {
-		return fmt.Errorf("failed to load store: %w", err)
+	scopes, err := writeScopes(storeLocal, storeBoth)
+	if err != nil {
+		return err
 	}
 
-	if autoDetectedType == "snippet" {
-		return storeSnippet(st, path)
+	for _, sc := range scopes {
+		st := store.NewStore(sc.Root)
+		if err := st.Load(); err != nil {
+			return fmt.Errorf("failed to load %s store: %w", sc.Name, err)
+		}
+
+		if autoDetectedType == "snippet" {
+			err = storeSnippet(st, sc, path)
+		} else {
+			err = storeStack(st, sc, path)
+		}
+		if err != nil {
+			return err
+		}
 	}
-	return storeStack(st, path)
+	return nil
 }
 
-func storeSnippet(st *store.Store, path string) error {
+func storeSnippet(st *store.Store, sc storeScope, path string) error {
 	// Must be a file
 	if utils.IsDirectory(path) {
 		return fmt.Errorf("snippet must be a file, not a directory")
@@ -135,6 +168,9 @@ func storeSnippet(st *store.Store, path string) error {
 	if err := utils.ValidateSnippetMetadata(meta); err != nil {
 		return fmt.Errorf("invalid snippet metadata: %w\n\nAdd required metadata comment:\n  // __author Your Name", err)
 	}
+	if err := meta.CheckVariables(); err != nil {
+		return fmt.Errorf("%w\n\nDeclare variables and blocks as:\n  // __var bl__NAME[:type] [= default] [-- description]\n  // __if bl__NAME | !bl__NAME | bl__NAME == a|b  ...  __else  ...  __endif", err)
+	}
 
 	// Use metadata name if no custom name provided
 	if storeName == "" || storeName == strings.TrimSuffix(filepath.Base(path), ext) {
@@ -147,7 +183,11 @@ func storeSnippet(st *store.Store, path string) error {
 
 	// Check if any version of this snippet exists
 	existingVersions := st.GetAllVersions(storeName, ext)
-	var version int
+	nextVersion, err := st.GetNextVersion(storeName, ext, storeBump)
+	if err != nil {
+		return err
+	}
+	version := nextVersion
 	var fullName string
 	
 	if len(existingVersions) > 0 {
@@ -155,8 +195,8 @@ func storeSnippet(st *store.Store, path string) error {
 		latestVersion := existingVersions[len(existingVersions)-1]
 		
 		choice, err := utils.Prompt(fmt.Sprintf(
-			"Snippet '%s' already exists (%d version(s)). Options:\n  (o) Overwrite latest version (%d)\n  (n) Create new version (%d)\n  (c) Cancel\nChoice: ",
-			storeName+ext, len(existingVersions), latestVersion, latestVersion+1))
+			"Snippet '%s' already exists (%d version(s)). Options:\n  (o) Overwrite latest version (%s)\n  (n) Create new version (%s)\n  (c) Cancel\nChoice: ",
+			storeName+ext, len(existingVersions), latestVersion, nextVersion))
 		if err != nil {
 			return fmt.Errorf("failed to read input: %w", err)
 		}
@@ -168,56 +208,67 @@ func storeSnippet(st *store.Store, path string) error {
 			version = latestVersion
 		case "n", "new":
 			// Create new version
-			version = latestVersion + 1
+			version = nextVersion
 		case "c", "cancel":
 			return fmt.Errorf("cancelled by user")
 		default:
 			return fmt.Errorf("invalid choice '%s'. Use 'o' for overwrite, 'n' for new version, or 'c' to cancel", choice)
 		}
-	} else {
-		// First version
-		version = 1
 	}
 
 	// Determine the language directory based on extension
 	langDir := strings.TrimPrefix(ext, ".")
-	snippetDir := filepath.Join(cfg.Paths.Snippets, langDir)
+	snippetDir := filepath.Join(sc.Snippets, langDir)
 	if err := utils.EnsureDir(snippetDir); err != nil {
 		return fmt.Errorf("failed to create snippet directory: %w", err)
 	}
 
 	// Build full name with version
-	fullName = fmt.Sprintf("%s@%d%s", storeName, version, ext)
+	fullName = store.FormatResourceName(storeName, version, ext)
 	destPath := filepath.Join(snippetDir, filepath.Base(fullName))
+	message := versionMessage(fullName)
 
-	// If overwriting, remove old version
-	if st.SnippetExists(fullName) {
-		if err := st.RemoveSnippet(fullName); err != nil {
-			return fmt.Errorf("failed to remove old snippet: %w", err)
-		}
-		if utils.FileExists(destPath) {
-			if err := os.Remove(destPath); err != nil {
-				return fmt.Errorf("failed to remove old file: %w", err)
-			}
-		}
+	// Stage the copy first so an overwritten version is only dropped once
+	// the new one is fully in place
+	tx, err := st.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Copy file
-	if err := utils.CopyFile(path, destPath); err != nil {
+	staged := tx.StagePath(filepath.Base(fullName))
+	if err := utils.CopyFile(path, staged); err != nil {
 		return fmt.Errorf("failed to copy snippet: %w", err)
 	}
+	if err := tx.Put(staged, destPath); err != nil {
+		return fmt.Errorf("failed to store snippet: %w", err)
+	}
 
 	// Add to metadata
-	if err := st.AddSnippet(fullName, destPath); err != nil {
+	entry, err := store.NewSnippetEntry(fullName, destPath)
+	if err != nil {
+		return fmt.Errorf("failed to read stored snippet: %w", err)
+	}
+	entry.Author = meta.Author
+	entry.Description = meta.Description
+	if storeDescription != "" {
+		entry.Description = storeDescription
+	}
+	entry.Message = message
+	if err := st.AddSnippet(entry); err != nil {
 		return fmt.Errorf("failed to update metadata: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 
 	fmt.Printf("✓ Stored snippet '%s' at %s\n", fullName, destPath)
 	logger.Info(fmt.Sprintf("Snippet stored: %s -> %s", path, destPath))
+	commitStore(sc.Root, "Store "+fullName)
 	return nil
 }
 
-func storeStack(st *store.Store, path string) error {
+func storeStack(st *store.Store, sc storeScope, path string) error {
 	// Must be a directory
 	if !utils.IsDirectory(path) {
 		return fmt.Errorf("stack must be a directory, not a file")
@@ -240,56 +291,103 @@ func storeStack(st *store.Store, path string) error {
 	// Use config ID as stack name
 	storeName = stackConfig.ID
 
-	// Parse version
-	version, err := strconv.Atoi(stackConfig.Version)
+	// Validate version; "1" and "1.0" are stored as 1.0.0
+	version, err := store.NormalizeVersion(stackConfig.Version)
 	if err != nil {
-		return fmt.Errorf("invalid version in boiler.stack.json: %s", stackConfig.Version)
+		return fmt.Errorf("invalid version in boiler.stack.json: %w", err)
 	}
+	fullName := store.FormatResourceName(storeName, version, "")
 
-	// Build paths
-	fullName := fmt.Sprintf("%s@%d", storeName, version)
-	stackDir := filepath.Join(cfg.Paths.Stacks, fullName)
-
-	// Check if this version already exists
-	if st.StackExists(fullName) {
+	// Check if this version already exists. Overwriting keeps the name an
+	// older store may have given it, e.g. express@1.
+	if existing, ok := st.GetStack(fullName); ok {
+		fullName = existing.FullName()
 		choice, err := utils.Prompt(fmt.Sprintf("Stack '%s' already exists. Overwrite? (y/n): ", fullName))
 		if err != nil || strings.ToLower(strings.TrimSpace(choice)) != "y" {
 			return fmt.Errorf("cancelled")
 		}
-		// Remove old version
-		if err := st.RemoveStack(fullName); err != nil {
-			return fmt.Errorf("failed to remove old stack: %w", err)
-		}
-		if utils.IsDirectory(stackDir) {
-			if err := os.RemoveAll(stackDir); err != nil {
-				return fmt.Errorf("failed to remove old directory: %w", err)
-			}
-		}
 	}
+	stackDir := filepath.Join(sc.Stacks, fullName)
+	message := versionMessage(fullName)
 
-	// Get ignore patterns from config
-	ignorePatterns := models.ResolveIgnorePatterns(stackConfig)
+	// Get ignore patterns from config. A project's own local store is never
+	// part of a stack.
+	ignorePatterns := append(models.ResolveIgnorePatterns(stackConfig), store.LocalDir)
 
-	// Copy directory
-	if err := utils.CopyDir(path, stackDir, ignorePatterns); err != nil {
+	// Stage the copy; an existing version is restored if anything fails
+	tx, err := st.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	staged := tx.StagePath(fullName)
+	if err := utils.CopyDir(path, staged, ignorePatterns); err != nil {
 		return fmt.Errorf("failed to copy stack: %w", err)
 	}
 
+	// Keep the config with the stored copy so the store can be checked and
+	// reindexed from disk alone
+	configFile := filepath.Join(path, store.StackConfigFile)
+	if err := utils.CopyFile(configFile, filepath.Join(staged, store.StackConfigFile)); err != nil {
+		return fmt.Errorf("failed to copy stack config: %w", err)
+	}
+	if err := tx.Put(staged, stackDir); err != nil {
+		return fmt.Errorf("failed to store stack: %w", err)
+	}
+
 	// Add to metadata
-	if err := st.AddStack(fullName, stackDir); err != nil {
+	entry, err := store.NewStackEntry(fullName, stackDir)
+	if err != nil {
+		return fmt.Errorf("failed to read stored stack: %w", err)
+	}
+	entry.Author = stackConfig.Author
+	entry.Description = stackConfig.Description
+	if storeDescription != "" {
+		entry.Description = storeDescription
+	}
+	entry.Message = message
+	if err := st.AddStack(entry); err != nil {
 		return fmt.Errorf("failed to update metadata: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 
 	fmt.Printf("✓ Stored stack '%s' at %s\n", fullName, stackDir)
 	logger.Info(fmt.Sprintf("Stack stored: %s -> %s", path, stackDir))
+	commitStore(sc.Root, "Store "+fullName)
 	return nil
 }
 
+// versionMessage returns the --message note, or asks for one. An empty
+// answer, or no input at all, stores the version without a note. The
+// answer is reused when storing into both stores.
+func versionMessage(fullName string) string {
+	if storeMessage != "" || storeMessageAsked {
+		return strings.TrimSpace(storeMessage)
+	}
+	storeMessageAsked = true
+
+	note, err := utils.Prompt(fmt.Sprintf("Note for '%s' (optional, Enter to skip): ", fullName))
+	if err != nil {
+		return ""
+	}
+	storeMessage = note
+	return note
+}
+
 var (
 	storeName        string
 	storeAsSnippet   bool
 	storeAsStack     bool
 	storeDescription string
+	storeBump        string
+	storeMessage     string
+	storeLocal       bool
+	storeBoth        bool
+
+	storeMessageAsked bool
 )
 
 func init() {
@@ -297,4 +395,8 @@ func init() {
 	storeCmd.Flags().BoolVarP(&storeAsSnippet, "snippet", "n", false, "Force store as snippet")
 	storeCmd.Flags().BoolVarP(&storeAsStack, "stack", "k", false, "Force store as stack")
 	storeCmd.Flags().StringVarP(&storeDescription, "description", "d", "", "Description")
+	storeCmd.Flags().BoolVarP(&storeLocal, FlagLocal, FlagLocalShort, false, "Store in the project-local .boiler/ store")
+	storeCmd.Flags().BoolVarP(&storeBoth, FlagBoth, FlagBothShort, false, "Store in both the local and the global store")
+	storeCmd.Flags().StringVarP(&storeMessage, "message", "m", "", "Note on what changed in this version")
+	storeCmd.Flags().StringVar(&storeBump, "bump", store.BumpPatch, "Version part to bump for a new snippet version (major, minor, patch)")
 }
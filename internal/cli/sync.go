@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rishiyaduwanshi/boiler/internal/gitsync"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync the store with its git remote",
+	Long: `Sync the global store with a git remote.
+
+The store directory must be a git working copy, e.g. a clone of your team's
+store repository. 'bl sync' then:
+
+  1. commits local changes with a generated message
+  2. pulls the remote branch, merging boiler.meta.json entry by entry
+  3. pushes the result
+
+While the store is a git working copy, 'bl store', 'bl clean' and
+'bl restore' commit their changes right away, so the history says what
+happened.
+
+If the same version was changed on both sides, the one updated last is kept.
+Conflicts in snippet or stack files stop the sync; resolve them with git in
+the store directory and run 'bl sync' again.
+
+The remote and branch come from the 'sync' section of boiler.conf.json
+(default: origin and the current branch).`,
+	Example: `  # Turn the store into a clone of the team repository
+  git clone git@example.com:team/boiler-store.git ~/.boiler/store
+
+  # Sync
+  bl sync
+
+  # Sync with another remote or branch
+  bl sync --remote upstream --branch main`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := syncStore(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func syncStore() error {
+	repo, err := gitsync.Open(cfg.Paths.Store)
+	if errors.Is(err, gitsync.ErrNotRepo) {
+		return fmt.Errorf("store %s is not a git repository; clone your store repository there, or run:\n  git -C %s init\n  git -C %s remote add origin <url>",
+			cfg.Paths.Store, cfg.Paths.Store, cfg.Paths.Store)
+	}
+	if err != nil {
+		return err
+	}
+
+	remote := syncRemote
+	if remote == "" {
+		remote = cfg.Sync.Remote
+	}
+	if remote == "" {
+		remote = "origin"
+	}
+	branch := syncBranch
+	if branch == "" {
+		branch = cfg.Sync.Branch
+	}
+
+	st, err := utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		return err
+	}
+
+	var res *gitsync.Result
+	err = st.WithLock(func() error {
+		res, err = repo.Sync(remote, branch)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	target := remote + "/" + res.Branch
+	if res.Committed {
+		fmt.Println("✓ Committed local changes")
+	}
+	if res.Pulled > 0 {
+		fmt.Printf("⬇ Pulled %d commit(s) from %s\n", res.Pulled, target)
+	}
+	for _, name := range res.Conflicts {
+		fmt.Printf("⚠ '%s' changed on both sides; kept the one updated last\n", name)
+	}
+	if res.Pushed {
+		fmt.Printf("⬆ Pushed to %s\n", target)
+	}
+	if !res.Committed && res.Pulled == 0 && !res.Pushed {
+		fmt.Printf("✓ Store is up to date with %s\n", target)
+	}
+
+	logger.Info(fmt.Sprintf("Store synced with %s (pulled %d, pushed %t)", target, res.Pulled, res.Pushed))
+	return nil
+}
+
+// commitStore commits a change to a store that is a git working copy, so
+// the history names what happened and 'bl sync' only merges and pushes.
+// Other stores are left alone.
+func commitStore(root, message string) {
+	repo, err := gitsync.Open(root)
+	if err != nil {
+		return
+	}
+	if _, err := repo.CommitAll(message); err != nil {
+		fmt.Printf("⚠ Failed to commit to the store repository: %v\n", err)
+		logger.Info(fmt.Sprintf("Store commit failed: %v", err))
+	}
+}
+
+var (
+	syncRemote string
+	syncBranch string
+)
+
+func init() {
+	syncCmd.Flags().StringVar(&syncRemote, "remote", "", "Git remote to sync with (default: sync.remote or origin)")
+	syncCmd.Flags().StringVar(&syncBranch, "branch", "", "Branch to sync (default: sync.branch or the current branch)")
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <resource[@version]> [tag]",
+	Short: "Point a tag such as stable or beta at a resource version",
+	Long: `Manage tags on stored snippets and stacks.
+
+A tag is a movable name for one version, e.g. 'stable' or 'beta'. Tagging
+again moves the tag. 'bl add name@tag' then resolves to the tagged version
+without prompting.
+
+The version can be exact, a constraint (^2, ~1.4) or another tag. Without a
+version the highest stored version is tagged. 'latest' is reserved and
+always means the highest version.
+
+With only a resource, the tags of that resource are listed.`,
+	Example: `  # Tag version 3 of logger.js as stable
+  bl tag logger@3.js stable
+
+  # Move beta to the highest 2.x version of a stack
+  bl tag express@^2 beta
+
+  # List tags
+  bl tag logger.js
+
+  # Remove a tag
+  bl tag logger.js stable --delete
+
+  # Use the tag
+  bl add logger@stable.js`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch {
+		case len(args) == 1:
+			err = listTags(args[0])
+		case tagDelete:
+			err = deleteTag(args[0], args[1])
+		default:
+			err = setTag(args[0], args[1])
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func setTag(resource, tag string) error {
+	st, err := utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		return err
+	}
+
+	baseName, spec, ext := store.ParseResourceName(resource)
+	fullName, _, err := resolveResource(st, baseName, spec, ext)
+	if err != nil {
+		return err
+	}
+
+	name, version, ext := store.ParseResourceName(fullName)
+	if err := st.SetTag(store.ResourceKey(name, ext), tag, version); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Tagged '%s' as %s\n", fullName, tag)
+	logger.Info(fmt.Sprintf("Tag set: %s -> %s", tag, fullName))
+	return nil
+}
+
+func deleteTag(resource, tag string) error {
+	st, err := utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		return err
+	}
+
+	key, err := tagKey(st, resource)
+	if err != nil {
+		return err
+	}
+	if err := st.RemoveTag(key, tag); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Removed tag %s from '%s'\n", tag, key)
+	logger.Info(fmt.Sprintf("Tag removed: %s from %s", tag, key))
+	return nil
+}
+
+func listTags(resource string) error {
+	st, err := utils.LoadStore(cfg.Paths.Store)
+	if err != nil {
+		return err
+	}
+
+	key, err := tagKey(st, resource)
+	if err != nil {
+		return err
+	}
+
+	tags := st.Tags(key)
+	if len(tags) == 0 {
+		fmt.Printf("No tags on '%s'\n", key)
+		return nil
+	}
+
+	names := make([]string, 0, len(tags))
+	for tag := range tags {
+		names = append(names, tag)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("🏷  Tags on '%s':\n", key)
+	for _, tag := range names {
+		fmt.Printf("  • %s → %s\n", tag, tags[tag])
+	}
+	return nil
+}
+
+// tagKey resolves a resource, with or without version, to the key its tags
+// are stored under
+func tagKey(st *store.Store, resource string) (string, error) {
+	baseName, _, ext := store.ParseResourceName(resource)
+	fullName, _, err := resolveResource(st, baseName, "", ext)
+	if err != nil {
+		return "", err
+	}
+	name, _, ext := store.ParseResourceName(fullName)
+	return store.ResourceKey(name, ext), nil
+}
+
+var tagDelete bool
+
+func init() {
+	tagCmd.Flags().BoolVarP(&tagDelete, "delete", "d", false, "Remove the tag instead of setting it")
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List or empty removed resources",
+	Long: `Snippets and stacks removed with 'bl clean' are moved to the trash under
+the Boiler root (~/.boiler/trash by default) together with their index
+entries and tags.
+
+Use 'bl restore <name>' to bring an item back.`,
+	Example: `  # List the trash
+  bl trash ls
+
+  # Delete items removed more than 30 days ago
+  bl trash empty --older-than 30d
+
+  # Delete everything in the trash
+  bl trash empty`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List removed snippets and stacks",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listTrash(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete items in the trash",
+	Long: `Permanently delete items in the trash.
+
+Without --older-than everything is deleted after confirmation. Ages are
+given as days (30d), weeks (2w) or any duration like 12h.`,
+	Example: `  # Delete items removed more than 30 days ago
+  bl trash empty --older-than 30d
+
+  # Delete everything without asking
+  bl trash empty --force`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := emptyTrash(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// openTrash returns the trash under the Boiler root
+func openTrash() *store.Trash {
+	return store.NewTrash(filepath.Join(cfg.Paths.Root, "trash"))
+}
+
+func listTrash() error {
+	items, err := openTrash().List()
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Println(utils.MsgTrashEmpty)
+		return nil
+	}
+
+	fmt.Println("🗑  Trash:")
+	for _, item := range items {
+		fmt.Printf("  • %-30s %-8s removed %s (%s ago)  [%s]\n",
+			item.Name, item.Kind, item.DeletedAt.Format("2006-01-02 15:04"), item.Age(), item.ID)
+	}
+	return nil
+}
+
+func emptyTrash() error {
+	trash := openTrash()
+
+	var olderThan time.Duration
+	if trashOlderThan != "" {
+		age, err := store.ParseAge(trashOlderThan)
+		if err != nil {
+			return err
+		}
+		olderThan = age
+	} else if !trashForce {
+		items, err := trash.List()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Println(utils.MsgTrashEmpty)
+			return nil
+		}
+		if !utils.ConfirmAction(fmt.Sprintf("Permanently delete %d item(s) in the trash? (y/N): ", len(items))) {
+			fmt.Println(utils.MsgCancelled)
+			return nil
+		}
+	}
+
+	purged, err := trash.Purge(olderThan)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Deleted %d item(s) from trash\n", purged)
+	logger.Info(fmt.Sprintf("Trash emptied: %d item(s)", purged))
+	return nil
+}
+
+var (
+	trashOlderThan string
+	trashForce     bool
+)
+
+func init() {
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+	trashEmptyCmd.Flags().StringVar(&trashOlderThan, "older-than", "", "Only delete items removed longer ago than this, e.g. 30d")
+	trashEmptyCmd.Flags().BoolVarP(&trashForce, FlagForce, FlagForceShort, false, "Delete without asking")
+}
@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/diff"
+	"github.com/rishiyaduwanshi/boiler/internal/lockfile"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update <name>...",
+	Short: "Update added resources to a newer version, keeping local edits",
+	Long: `Update snippets and stacks recorded in boiler.lock.json to a newer stored
+version.
+
+The new version is rendered with the variable values recorded when it was
+added; only variables the new version introduces are asked for. --set,
+--values and BL_VAR_* environment variables give or change values as for
+'bl add', and --no-input fails instead of prompting. Each file is
+then merged three ways: the original render of the added version is the
+base, the file in your project is yours and the new render is theirs. Your
+edits are kept, and where both sides changed the same lines the file gets
+conflict markers to resolve by hand:
+
+  <<<<<<< local
+  your lines
+  =======
+  the new version's lines
+  >>>>>>> logger@2.0.0.js
+
+Files removed by the new version are deleted unless you changed them, and
+files you deleted stay deleted. The version recorded for the added version
+must still be stored (or be in the registry) to serve as the base.
+
+A name selects entries by resource name (logger, logger.js) or by the path
+in the lock file. Without a version the latest stored one is used; versions,
+constraints (^2) and tags (stable) pick another.`,
+	Example: `  # Update a snippet to the latest version
+  bl update logger.js
+
+  # Update a stack to the version tagged stable
+  bl update express@stable
+
+  # Update whatever was added to a path
+  bl update src/utils/logger.js`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runUpdate(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	updateSkipVerify bool
+	updateVars       variableInput
+)
+
+func init() {
+	updateCmd.Flags().BoolVar(&updateSkipVerify, FlagSkipVerify, false, DescSkipVerify)
+	updateVars.register(updateCmd)
+}
+
+// Outcomes of updating a single file
+const (
+	fileUnchanged = "unchanged"
+	fileUpdated   = "updated"
+	fileMerged    = "merged"
+	fileConflict  = "conflict"
+	fileAdded     = "added"
+	fileRemoved   = "removed"
+	fileKept      = "kept"
+	fileSkipped   = "skipped"
+)
+
+func runUpdate(names []string) error {
+	lock, err := requireLockfile()
+	if err != nil {
+		return err
+	}
+	scopes, err := readScopes(false, false)
+	if err != nil {
+		return err
+	}
+	stores, err := loadScopes(scopes)
+	if err != nil {
+		return err
+	}
+
+	conflicted := 0
+	for _, name := range names {
+		baseName, spec, ext := store.ParseResourceName(name)
+		matched := matchLocked(lock, name, baseName, ext)
+		if len(matched) == 0 {
+			return fmt.Errorf("'%s' is not in %s", name, lock.Path())
+		}
+
+		for _, e := range matched {
+			n, err := updateEntry(scopes, stores, lock, &e, spec)
+			if err != nil {
+				return err
+			}
+			conflicted += n
+			// Record each finished update, even if a later one fails. Save
+			// sorts the entries, so the update is put back by resource and
+			// path rather than by position.
+			lock.Put(e)
+			if err := lock.Save(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if conflicted > 0 {
+		return fmt.Errorf("%d file(s) have conflicts; resolve the %s markers and review the result", conflicted, diff.MarkerOurs)
+	}
+	return nil
+}
+
+// matchLocked returns copies of the lock file entries a name selects: by
+// path, or by resource name and extension
+func matchLocked(lock *lockfile.Lockfile, name, baseName, ext string) []lockfile.Entry {
+	rel, relErr := lock.Rel(name)
+
+	var matched []lockfile.Entry
+	for _, e := range lock.Resources {
+		byPath := relErr == nil && rel == e.Path
+		byName := e.Name == baseName && (ext == "" || ext == e.Extension)
+		if byPath || byName {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// updateEntry updates one lock file entry to the version spec selects and
+// returns the number of files left with conflicts
+func updateEntry(scopes []storeScope, stores []scopedStore, lock *lockfile.Lockfile, e *lockfile.Entry, spec string) (int, error) {
+	st, _, err := lookupStore(scopes, e.Name, e.Extension)
+	if err != nil {
+		return 0, err
+	}
+	if spec == "" {
+		spec = "latest"
+	}
+	target, isStack, err := resolveResource(st, e.Name, spec, e.Extension)
+	if err != nil {
+		return 0, err
+	}
+	if isStack != (e.Kind == lockfile.KindStack) {
+		return 0, fmt.Errorf("'%s' is a %s in %s but resolves to '%s'", e.Key(), e.Kind, lockfile.FileName, target)
+	}
+
+	_, version, _ := store.ParseResourceName(target)
+	if store.CompareVersions(version, e.Version) == 0 {
+		fmt.Printf("✓ %s (%s) is up to date\n", e.FullName(), e.Path)
+		return 0, nil
+	}
+
+	// Check the new version and read what it needs
+	var checksum string
+	values := e.Variables
+	if isStack {
+		entry, _ := st.GetStack(target)
+		checksum = entry.Checksum
+		if err := checkIntegrity(store.VerifyStack(entry), updateSkipVerify); err != nil {
+			return 0, err
+		}
+	} else {
+		entry, _ := st.GetSnippet(target)
+		checksum = entry.Checksum
+		if err := checkIntegrity(store.VerifySnippet(entry), updateSkipVerify); err != nil {
+			return 0, err
+		}
+		meta, err := utils.ParseSnippetMetadata(entry.Path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse snippet metadata: %w", err)
+		}
+		if err := meta.CheckVariables(); err != nil {
+			return 0, fmt.Errorf("snippet '%s': %w", target, err)
+		}
+		if values, err = updateVars.resolve(meta, e.Variables, filepath.Dir(lock.Abs(e.Path))); err != nil {
+			return 0, err
+		}
+	}
+
+	// The base is the added version rendered as it was back then
+	baseStore, err := lockedStore(stores, *e)
+	if err != nil {
+		return 0, fmt.Errorf("cannot merge without '%s': %w", e.FullName(), err)
+	}
+	base, err := renderLocked(baseStore, *e, e.FullName(), e.Variables)
+	if err != nil {
+		return 0, err
+	}
+	theirs, err := renderLocked(st, *e, target, values)
+	if err != nil {
+		return 0, err
+	}
+
+	fmt.Printf("⬆ Updating %s %s → %s (%s)\n", e.Key(), e.Version, version, e.Path)
+	conflicts := 0
+	var files []string
+	for _, p := range sortedPaths(base, theirs) {
+		b, hasBase := base[p]
+		t, hasTheirs := theirs[p]
+		outcome, detail, err := updateFile(lock.Abs(p), b, t, hasBase, hasTheirs, target)
+		if err != nil {
+			return conflicts, err
+		}
+		if outcome == fileConflict {
+			conflicts++
+		}
+		if outcome != fileUnchanged {
+			fmt.Printf("  %-9s %s%s\n", outcome, p, detail)
+		}
+		if hasTheirs && outcome != fileSkipped {
+			files = append(files, p)
+		}
+	}
+	logger.Info(fmt.Sprintf("Updated %s from %s to %s in %s (%d conflicts)", e.Key(), e.Version, version, e.Path, conflicts))
+
+	sort.Strings(files)
+	e.Version = version
+	e.Checksum = checksum
+	e.Variables = values
+	e.Files = files
+	return conflicts, nil
+}
+
+// updateFile brings one file at path from the base render to the new one,
+// keeping local changes, and reports what it did
+func updateFile(path string, base, theirs renderedFile, hasBase, hasTheirs bool, label string) (string, string, error) {
+	ours, err := os.ReadFile(path)
+	hasOurs := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", err
+	}
+
+	switch {
+	case !hasTheirs:
+		// Removed by the new version
+		if !hasOurs {
+			return fileUnchanged, "", nil
+		}
+		if hasBase && bytes.Equal(ours, base.data) {
+			if err := os.Remove(path); err != nil {
+				return "", "", err
+			}
+			return fileRemoved, "", nil
+		}
+		return fileKept, " (removed in the new version, changed locally)", nil
+	case !hasOurs:
+		if hasBase {
+			return fileSkipped, " (deleted locally)", nil
+		}
+		return fileAdded, "", writeRendered(path, theirs)
+	case bytes.Equal(ours, theirs.data):
+		return fileUnchanged, "", nil
+	case hasBase && bytes.Equal(ours, base.data):
+		return fileUpdated, "", writeRendered(path, theirs)
+	case hasBase && bytes.Equal(theirs.data, base.data):
+		return fileUnchanged, "", nil
+	}
+
+	if isBinary(ours) || isBinary(theirs.data) {
+		return fileConflict, " (binary file changed on both sides; kept local)", nil
+	}
+
+	var baseLines []string
+	if hasBase {
+		baseLines = diff.SplitLines(string(base.data))
+	}
+	merged, conflicts := diff.Merge3(baseLines, diff.SplitLines(string(ours)), diff.SplitLines(string(theirs.data)), "local", label)
+	content := renderedFile{data: []byte(strings.Join(merged, "\n") + "\n"), mode: theirs.mode}
+	if err := writeRendered(path, content); err != nil {
+		return "", "", err
+	}
+	if conflicts > 0 {
+		return fileConflict, fmt.Sprintf(" (%d conflicting hunk(s))", conflicts), nil
+	}
+	return fileMerged, "", nil
+}
+
+// writeRendered writes a rendered file, keeping the mode of a file that
+// is already there
+func writeRendered(path string, f renderedFile) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	mode := f.mode
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, f.data, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
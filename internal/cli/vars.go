@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// variableInput holds the template variable values given without
+// prompting: --set, a --values file and BL_VAR_* environment variables
+type variableInput struct {
+	set     []string
+	values  string
+	noInput bool
+}
+
+// givenValue is a value from a variableInput and where it came from
+type givenValue struct {
+	value  string
+	source string
+}
+
+func (in *variableInput) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&in.set, "set", nil, "Set a template variable, e.g. --set bl__API_URL=https://api.example.com (repeatable)")
+	cmd.Flags().StringVar(&in.values, "values", "", "Read template variables from a .json or .env file")
+	cmd.Flags().BoolVar(&in.noInput, "no-input", false, "Never prompt; fail and list required variables that have no value")
+}
+
+// given returns the values by variable name. --set wins over the values
+// file, which wins over the environment.
+func (in *variableInput) given() (map[string]givenValue, error) {
+	given := make(map[string]givenValue)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(key, utils.VarEnvPrefix) {
+			given[utils.VariableName(key)] = givenValue{value, key}
+		}
+	}
+
+	if in.values != "" {
+		values, err := utils.ReadVariableValues(in.values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --values: %w", err)
+		}
+		for key, value := range values {
+			given[utils.VariableName(key)] = givenValue{value, in.values}
+		}
+	}
+
+	for _, kv := range in.set {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("--set '%s': expected NAME=value", kv)
+		}
+		given[utils.VariableName(key)] = givenValue{value, "--set"}
+	}
+	return given, nil
+}
+
+// resolve returns a valid value for each variable the snippet uses.
+// Reserved variables are worked out for files added to dir. Given values
+// come first, then valid values in known, and the rest are prompted for in
+// declaration order, offering their defaults; computed defaults are filled
+// from the values before them. With --no-input defaults are taken as they
+// are and required variables without a value are an error.
+func (in *variableInput) resolve(meta *utils.SnippetMetadata, known map[string]string, dir string) (map[string]string, error) {
+	given, err := in.given()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	declared := make(map[string]bool)
+
+	// Reserved variables used without a declaration are never asked for
+	for _, name := range meta.Builtins {
+		declared[name] = true
+		if g, ok := given[name]; ok {
+			values[name] = g.value
+			continue
+		}
+		if value, ok := known[name]; ok {
+			values[name] = value
+			continue
+		}
+		value, err := utils.BuiltinValue(name, dir)
+		if err != nil {
+			fmt.Printf("⚠ %s is left empty: %v\n", name, err)
+		}
+		values[name] = value
+	}
+
+	var invalid []string
+	var pending []utils.Variable
+	for _, v := range meta.Variables {
+		declared[v.Name] = true
+		if g, ok := given[v.Name]; ok {
+			value, err := v.Check(g.value)
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("  %s from %s: %v", v.Name, g.source, err))
+				continue
+			}
+			values[v.Name] = value
+			continue
+		}
+		if value, ok := known[v.Name]; ok {
+			if value, err := v.Check(value); err == nil {
+				values[v.Name] = value
+				continue
+			}
+		}
+		// A declared reserved variable offers its value as the default
+		if utils.IsBuiltin(v.Name) && !v.HasDefault {
+			if value, err := utils.BuiltinValue(v.Name, dir); err == nil {
+				v.Default, v.HasDefault = value, true
+			}
+		}
+		pending = append(pending, v)
+	}
+
+	var unknown []string
+	for name, g := range given {
+		if g.source == "--set" && !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		fmt.Printf("⚠ --set %s: no such variable is declared\n", name)
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid template variable values:\n%s", strings.Join(invalid, "\n"))
+	}
+
+	var missing []string
+	asked := false
+	for _, v := range pending {
+		v.Default = v.ExpandDefault(values)
+		if in.noInput {
+			if v.Required() {
+				missing = append(missing, variableLabel(v))
+				continue
+			}
+			value, err := v.Check(v.Default)
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("  %s default: %v", v.Name, err))
+				continue
+			}
+			values[v.Name] = value
+			continue
+		}
+
+		if !asked {
+			fmt.Println("Template variables found:")
+			asked = true
+		}
+		value, err := promptVariable(v)
+		if err != nil {
+			return nil, err
+		}
+		values[v.Name] = value
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid template variable values:\n%s", strings.Join(invalid, "\n"))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required template variables:\n%s\nset them with --set NAME=value, a --values file or %sNAME environment variables",
+			strings.Join(missing, "\n"), utils.VarEnvPrefix)
+	}
+	return values, nil
+}
+
+// variableLabel describes a variable with its accepted values and its
+// description, e.g. "  bl__PORT (int) - Port the server listens on"
+func variableLabel(v utils.Variable) string {
+	label := fmt.Sprintf("  %s", v.Name)
+	if t := v.TypeLabel(); t != "" {
+		label += " (" + t + ")"
+	}
+	if v.Description != "" {
+		label += " - " + v.Description
+	}
+	return label
+}
+
+// promptVariable asks for one variable until the answer is valid
+func promptVariable(v utils.Variable) (string, error) {
+	prompt := variableLabel(v)
+	for {
+		var input string
+		var err error
+		if v.Required() {
+			input, err = utils.Prompt(prompt + ": ")
+		} else {
+			input, err = utils.PromptWithDefault(prompt, v.Default)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read variable input: %w", err)
+		}
+
+		value, err := v.Check(input)
+		if err == nil {
+			return value, nil
+		}
+		fmt.Printf("    ✗ %v\n", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
 )
 
 type Config struct {
@@ -16,7 +18,10 @@ type Config struct {
 	Description   string            `json:"description"`
 	DefaultEditor string            `json:"defaultEditor"`
 	Registry      string            `json:"registry"`
+	RegistryToken string            `json:"registryToken,omitempty"`
 	Paths         Paths             `json:"paths"`
+	Sync          Sync              `json:"sync"`
+	Signing       Signing           `json:"signing"`
 	Artifacts     map[string]string `json:"artifacts"`
 	Aliases       map[string]string `json:"aliases"`
 }
@@ -30,6 +35,27 @@ type Paths struct {
 	Bin      string `json:"bin"`
 }
 
+// Sync configures 'bl sync' for a store that is a git working copy
+type Sync struct {
+	Remote string `json:"remote"`
+	// Branch to sync; empty means the store's current branch
+	Branch string `json:"branch"`
+}
+
+// Signing configures signing of exports and publishes, and verification
+// of imports and remote adds
+type Signing struct {
+	// Policy for unsigned or untrusted content: warn, require or off
+	Policy string `json:"policy"`
+	// Key is the private key file; empty means ~/.boiler/keys/boiler.key
+	Key         string               `json:"key,omitempty"`
+	TrustedKeys []signing.TrustedKey `json:"trustedKeys"`
+}
+
+// legacyRegistry is the placeholder registry older configs were created
+// with. It is a web page, not a registry, so it counts as none.
+const legacyRegistry = "https://github.com/rishiyaduwanshi/boiler/store"
+
 func DefaultConfig() *Config {
 	return &Config{
 		Name:          "Boiler",
@@ -38,7 +64,7 @@ func DefaultConfig() *Config {
 		Github:        "github.com/rishiyaduwanshi/boiler",
 		Description:   "A CLI tool to manage reusable code snippets and stacks",
 		DefaultEditor: "vim",
-		Registry:      "https://github.com/rishiyaduwanshi/boiler/store",
+		Registry:      "",
 		Paths: Paths{
 			Root:     "~/.boiler",
 			Store:    "~/.boiler/store",
@@ -47,6 +73,13 @@ func DefaultConfig() *Config {
 			Logs:     "~/.boiler/logs",
 			Bin:      "~/.boiler/bin",
 		},
+		Sync: Sync{
+			Remote: "origin",
+		},
+		Signing: Signing{
+			Policy:      signing.PolicyWarn,
+			TrustedKeys: []signing.TrustedKey{},
+		},
 		Artifacts: map[string]string{
 			"default":    "//  ",
 			"bl":         "//  ",
@@ -143,6 +176,9 @@ func Load() (*Config, error) {
 	}
 
 	cfg.Paths.ExpandPaths()
+	if strings.TrimRight(cfg.Registry, "/") == legacyRegistry {
+		cfg.Registry = ""
+	}
 
 	return &cfg, nil
 }
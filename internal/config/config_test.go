@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+func TestLoadDropsLegacyRegistry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Registry != "" {
+		t.Fatalf("default registry = %q, want none", cfg.Registry)
+	}
+
+	// Configs created by older versions carry a placeholder URL
+	cfg.Registry = legacyRegistry
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Registry != "" {
+		t.Errorf("registry = %q after loading the legacy default, want none", cfg.Registry)
+	}
+
+	cfg.Registry = "https://registry.example.com"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Registry != "https://registry.example.com" {
+		t.Errorf("registry = %q, want the configured one", cfg.Registry)
+	}
+}
@@ -0,0 +1,238 @@
+// Package diff computes line diffs and renders them in unified format
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind says what happened to a line
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of a diff
+type Edit struct {
+	Kind Kind
+	Line string
+}
+
+// DefaultContext is the number of unchanged lines shown around a change
+const DefaultContext = 3
+
+// SplitLines splits text into lines without their trailing newline
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Lines returns the shortest edit script turning a into b. It uses the
+// linear-space variant of Myers' algorithm: the middle snake of the edit
+// path splits the problem in two, so memory stays O(len(a)+len(b)) however
+// different the inputs are.
+func Lines(a, b []string) []Edit {
+	size := len(a) + len(b) + 5
+	d := &differ{a: a, b: b, vf: make([]int, size), vb: make([]int, size)}
+	d.diff(0, len(a), 0, len(b))
+	return d.edits
+}
+
+// differ holds the inputs, the edits found so far and the diagonal arrays
+// the middle snake search reuses at every level of the recursion
+type differ struct {
+	a, b   []string
+	vf, vb []int
+	edits  []Edit
+}
+
+// diff appends the edits turning a[aLo:aHi] into b[bLo:bHi]
+func (d *differ) diff(aLo, aHi, bLo, bHi int) {
+	// Common lines at both ends are never part of an edit
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.edits = append(d.edits, Edit{Equal, d.a[aLo]})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-1-suffix] == d.b[bHi-1-suffix] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		for _, line := range d.b[bLo:bHi] {
+			d.edits = append(d.edits, Edit{Insert, line})
+		}
+	case bLo == bHi:
+		for _, line := range d.a[aLo:aHi] {
+			d.edits = append(d.edits, Edit{Delete, line})
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.diff(aLo, x, bLo, y)
+		for _, line := range d.a[x:u] {
+			d.edits = append(d.edits, Edit{Equal, line})
+		}
+		d.diff(u, aHi, v, bHi)
+	}
+
+	for _, line := range d.a[aHi : aHi+suffix] {
+		d.edits = append(d.edits, Edit{Equal, line})
+	}
+}
+
+// middleSnake searches the shortest edit path from both ends at once and
+// returns the snake, from (x, y) to (u, v), where the two searches meet.
+// Both ranges are non-empty and differ in their first and last lines, so
+// the path has at least two edits and each half is smaller than the whole.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	off := maxD + 1
+	vf, vb := d.vf, d.vb
+	vf[off+1], vb[off+1] = 0, 0
+
+	for step := 0; step <= maxD; step++ {
+		// Forward from (aLo, bLo); vf holds the furthest x per diagonal k = x-y
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			if kr := delta - k; odd && kr >= -(step-1) && kr <= step-1 && x+vb[off+kr] >= n {
+				return aLo + x0, bLo + y0, aLo + x, bLo + y
+			}
+		}
+
+		// Backward from (aHi, bHi), counting x and y from the ends
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			vb[off+k] = x
+			if kf := delta - k; !odd && kf >= -step && kf <= step && vf[off+kf]+x >= n {
+				return aHi - x, bHi - y, aHi - x0, bHi - y0
+			}
+		}
+	}
+	panic("diff: no middle snake")
+}
+
+// Unified renders the difference between a and b as a unified diff. It
+// returns an empty string when both sides are equal.
+func Unified(fromName, toName string, a, b []string, context int) string {
+	edits := Lines(a, b)
+
+	// Line positions in a and b before each edit
+	aPos := make([]int, len(edits)+1)
+	bPos := make([]int, len(edits)+1)
+	changed := false
+	for i, e := range edits {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if e.Kind != Insert {
+			aPos[i+1]++
+		}
+		if e.Kind != Delete {
+			bPos[i+1]++
+		}
+		if e.Kind != Equal {
+			changed = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	i := 0
+	for i < len(edits) {
+		for i < len(edits) && edits[i].Kind == Equal {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for {
+			for end < len(edits) && edits[end].Kind != Equal {
+				end++
+			}
+			next := end
+			for next < len(edits) && edits[next].Kind == Equal {
+				next++
+			}
+			if next == len(edits) || next-end > 2*context {
+				end = min(end+context, len(edits))
+				break
+			}
+			end = next
+		}
+
+		writeHunk(&sb, edits[start:end], aPos[start], aPos[end], bPos[start], bPos[end])
+		i = end
+	}
+
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, edits []Edit, aStart, aEnd, bStart, bEnd int) {
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(aStart, aEnd-aStart), hunkRange(bStart, bEnd-bStart))
+	for _, e := range edits {
+		switch e.Kind {
+		case Equal:
+			sb.WriteString(" ")
+		case Delete:
+			sb.WriteString("-")
+		case Insert:
+			sb.WriteString("+")
+		}
+		sb.WriteString(e.Line)
+		sb.WriteString("\n")
+	}
+}
+
+// hunkRange formats a hunk range the way GNU diff does: an empty range
+// names the line before it, a single line omits the count
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
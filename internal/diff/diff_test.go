@@ -0,0 +1,117 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+// apply checks that edits turn a into b and returns the number of changes
+func apply(t *testing.T, a, b []string, edits []Edit) int {
+	t.Helper()
+	var gotA, gotB []string
+	changes := 0
+	for _, e := range edits {
+		if e.Kind != Insert {
+			gotA = append(gotA, e.Line)
+		}
+		if e.Kind != Delete {
+			gotB = append(gotB, e.Line)
+		}
+		if e.Kind != Equal {
+			changes++
+		}
+	}
+	if fmt.Sprint(gotA) != fmt.Sprint(a) || fmt.Sprint(gotB) != fmt.Sprint(b) {
+		t.Fatalf("edits do not turn %v into %v: %v", a, b, edits)
+	}
+	return changes
+}
+
+// lcs returns the length of the longest common subsequence
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestLinesIsShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		got := apply(t, a, b, Lines(a, b))
+		if want := len(a) + len(b) - 2*lcs(a, b); got != want {
+			t.Fatalf("Lines(%v, %v) has %d changes, want %d", a, b, got, want)
+		}
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := SplitLines("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n")
+	b := SplitLines("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n")
+
+	want := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if got := Unified("old", "new", a, b, 3); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+	if got := Unified("old", "new", a, a, 3); got != "" {
+		t.Errorf("Unified() of equal sides = %q, want empty", got)
+	}
+}
+
+func TestLinesLargeInputsStayLinear(t *testing.T) {
+	const n = 6000
+	a := make([]string, n)
+	b := make([]string, n)
+	for i := range a {
+		a[i] = fmt.Sprintf("a%d", i)
+		b[i] = fmt.Sprintf("b%d", i)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	edits := Lines(a, b)
+	runtime.ReadMemStats(&after)
+
+	if got := apply(t, a, b, edits); got != 2*n {
+		t.Fatalf("got %d changes, want %d", got, 2*n)
+	}
+	// The edits themselves take about 1 MB; quadratic memory would be GBs
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 16<<20 {
+		t.Fatalf("Lines allocated %d MB for %d lines", alloc>>20, n)
+	}
+}
@@ -0,0 +1,89 @@
+package diff
+
+// Markers written around the two sides of a merge conflict
+const (
+	MarkerOurs   = "<<<<<<<"
+	MarkerSep    = "======="
+	MarkerTheirs = ">>>>>>>"
+)
+
+// Merge3 applies both the changes from base to ours and from base to theirs.
+// Where the two sides changed the same lines differently, both versions are
+// kept between conflict markers labelled oursLabel and theirsLabel. It
+// returns the merged lines and the number of conflicts.
+func Merge3(base, ours, theirs []string, oursLabel, theirsLabel string) ([]string, int) {
+	toOurs := matches(base, ours)
+	toTheirs := matches(base, theirs)
+
+	var merged []string
+	conflicts := 0
+	i, j, k := 0, 0, 0
+	for i < len(base) || j < len(ours) || k < len(theirs) {
+		// A base line kept at the current position on both sides
+		if i < len(base) && toOurs[i] == j && toTheirs[i] == k {
+			merged = append(merged, base[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// Otherwise the chunk runs up to the next base line both sides kept
+		ni := i
+		for ni < len(base) && (toOurs[ni] < 0 || toTheirs[ni] < 0) {
+			ni++
+		}
+		nj, nk := len(ours), len(theirs)
+		if ni < len(base) {
+			nj, nk = toOurs[ni], toTheirs[ni]
+		}
+
+		b, o, t := base[i:ni], ours[j:nj], theirs[k:nk]
+		switch {
+		case equal(o, b):
+			merged = append(merged, t...)
+		case equal(t, b), equal(o, t):
+			merged = append(merged, o...)
+		default:
+			conflicts++
+			merged = append(merged, MarkerOurs+" "+oursLabel)
+			merged = append(merged, o...)
+			merged = append(merged, MarkerSep)
+			merged = append(merged, t...)
+			merged = append(merged, MarkerTheirs+" "+theirsLabel)
+		}
+		i, j, k = ni, nj, nk
+	}
+	return merged, conflicts
+}
+
+// matches maps each line of a to the line of b it is kept as, or -1 if it
+// was deleted
+func matches(a, b []string) []int {
+	m := make([]int, len(a))
+	ai, bi := 0, 0
+	for _, e := range Lines(a, b) {
+		switch e.Kind {
+		case Equal:
+			m[ai] = bi
+			ai++
+			bi++
+		case Delete:
+			m[ai] = -1
+			ai++
+		case Insert:
+			bi++
+		}
+	}
+	return m
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	base := "import x\n\nfunc a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 2\n}\n"
+
+	tests := []struct {
+		name          string
+		ours, theirs  string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:   "both sides edit different places",
+			ours:   "import x\nimport y\n\nfunc a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 2\n}\n",
+			theirs: "import x\n\nfunc a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 20\n}\n",
+			want:   "import x\nimport y\n\nfunc a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 20\n}\n",
+		},
+		{
+			name:   "same change on both sides",
+			ours:   "import x\n\nfunc a() {\n\treturn 10\n}\n\nfunc b() {\n\treturn 2\n}\n",
+			theirs: "import x\n\nfunc a() {\n\treturn 10\n}\n\nfunc b() {\n\treturn 2\n}\n",
+			want:   "import x\n\nfunc a() {\n\treturn 10\n}\n\nfunc b() {\n\treturn 2\n}\n",
+		},
+		{
+			name:   "deletion on one side",
+			ours:   "import x\n\nfunc a() {\n\treturn 1\n}\n",
+			theirs: "import z\n\nfunc a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 2\n}\n",
+			want:   "import z\n\nfunc a() {\n\treturn 1\n}\n",
+		},
+		{
+			name:          "conflicting edits",
+			ours:          "import x\n\nfunc a() {\n\treturn 100\n}\n\nfunc b() {\n\treturn 2\n}\n",
+			theirs:        "import x\n\nfunc a() {\n\treturn 1000\n}\n\nfunc b() {\n\treturn 2\n}\n",
+			want:          "import x\n\nfunc a() {\n<<<<<<< local\n\treturn 100\n=======\n\treturn 1000\n>>>>>>> new\n}\n\nfunc b() {\n\treturn 2\n}\n",
+			wantConflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := Merge3(SplitLines(base), SplitLines(tt.ours), SplitLines(tt.theirs), "local", "new")
+			got := strings.Join(merged, "\n") + "\n"
+			if got != tt.want || conflicts != tt.wantConflicts {
+				t.Fatalf("Merge3() = %d conflicts\n%s\nwant %d conflicts\n%s", conflicts, got, tt.wantConflicts, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+// Package gitsync keeps a store directory in sync with a git remote. The
+// store is a plain git working copy: local changes are committed, the
+// remote branch is merged with boiler.meta.json merged entry by entry, and
+// the result is pushed back.
+package gitsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+)
+
+// Default identity for commits when git has none configured
+const (
+	defaultName  = "Boiler"
+	defaultEmail = "boiler@localhost"
+)
+
+// ErrNotRepo is returned when the store is not the root of a git working copy
+var ErrNotRepo = errors.New("not a git repository")
+
+// Repo is a store directory that is the root of a git working copy
+type Repo struct {
+	Dir string
+	env []string
+}
+
+// Result describes what a Sync did
+type Result struct {
+	Branch string
+	// Committed is true when local changes were committed before merging
+	Committed bool
+	// Pulled is the number of commits merged from the remote
+	Pulled int
+	// Pushed is true when the remote branch was updated
+	Pushed bool
+	// Conflicts lists meta entries changed on both sides; the newer one won
+	Conflicts []string
+}
+
+// Open returns the repo for dir, or ErrNotRepo when dir is not the top
+// level of a git working copy. A store nested in another repository, like
+// a project-local store, is not a repo of its own.
+func Open(dir string) (*Repo, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not installed: %w", err)
+	}
+
+	r := &Repo{Dir: abs}
+	top, err := r.git("rev-parse", "--show-toplevel")
+	if err != nil || !sameDir(top, abs) {
+		return nil, ErrNotRepo
+	}
+
+	// Commits and merges need an identity; never fail a sync for lack of one
+	if email, _ := r.git("config", "user.email"); email == "" {
+		r.env = []string{
+			"GIT_AUTHOR_NAME=" + defaultName, "GIT_AUTHOR_EMAIL=" + defaultEmail,
+			"GIT_COMMITTER_NAME=" + defaultName, "GIT_COMMITTER_EMAIL=" + defaultEmail,
+		}
+	}
+	return r, nil
+}
+
+// IsRepo reports whether dir is the top level of a git working copy
+func IsRepo(dir string) bool {
+	_, err := Open(dir)
+	return err == nil
+}
+
+// CommitAll commits every change in the working copy with message. It
+// reports false when there was nothing to commit.
+func (r *Repo) CommitAll(message string) (bool, error) {
+	if err := store.EnsureGitignore(r.Dir); err != nil {
+		return false, err
+	}
+	if _, err := r.git("add", "-A"); err != nil {
+		return false, err
+	}
+	if r.clean() {
+		return false, nil
+	}
+	if _, err := r.git("commit", "-m", message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PendingMessage returns a generated commit message for the uncommitted
+// changes, naming the resources that were added, changed or removed
+func (r *Repo) PendingMessage() (string, error) {
+	out, err := r.git("status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return "", err
+	}
+
+	changes := map[string]map[string]bool{"Add": {}, "Update": {}, "Remove": {}}
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		code, file := strings.TrimSpace(line[:2]), strings.Trim(line[3:], `"`)
+		if i := strings.Index(file, " -> "); i >= 0 {
+			file = file[i+4:]
+		}
+		name := resourceName(file)
+		if name == "" {
+			continue
+		}
+		switch {
+		case code == "??" || strings.Contains(code, "A"):
+			changes["Add"][name] = true
+		case strings.Contains(code, "D"):
+			changes["Remove"][name] = true
+		default:
+			changes["Update"][name] = true
+		}
+	}
+
+	var parts []string
+	for _, verb := range []string{"Add", "Update", "Remove"} {
+		names := make([]string, 0, len(changes[verb]))
+		for name := range changes[verb] {
+			if verb == "Update" && (changes["Add"][name] || changes["Remove"][name]) {
+				continue
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+		parts = append(parts, verb+" "+strings.Join(names, ", "))
+	}
+	if len(parts) == 0 {
+		return "Update store", nil
+	}
+	return strings.Join(parts, "; "), nil
+}
+
+// resourceName maps a store-relative file to the resource it belongs to:
+// snippets/<lang>/<full name> or stacks/<full name>/...
+func resourceName(file string) string {
+	parts := strings.Split(path.Clean(file), "/")
+	switch {
+	case parts[0] == "snippets" && len(parts) == 3:
+		return parts[2]
+	case parts[0] == "stacks" && len(parts) >= 2:
+		return parts[1]
+	}
+	return ""
+}
+
+// Sync commits local changes, merges branch from remote and pushes the
+// result. An empty branch means the current one. The caller should hold
+// the store lock.
+func (r *Repo) Sync(remote, branch string) (*Result, error) {
+	if branch == "" {
+		current, err := r.git("symbolic-ref", "--short", "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("cannot sync a detached HEAD: %w", err)
+		}
+		branch = current
+	}
+	res := &Result{Branch: branch}
+
+	message, err := r.PendingMessage()
+	if err != nil {
+		return nil, err
+	}
+	if res.Committed, err = r.CommitAll(message); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.git("fetch", remote); err != nil {
+		return nil, err
+	}
+
+	theirs := "refs/remotes/" + remote + "/" + branch
+	if r.exists(theirs) {
+		if err := r.merge(theirs, remote+"/"+branch, res); err != nil {
+			return nil, err
+		}
+	}
+
+	if !r.exists("HEAD") {
+		return res, nil
+	}
+	if r.exists(theirs) && r.revision("HEAD") == r.revision(theirs) {
+		return res, nil
+	}
+	if _, err := r.git("push", remote, "HEAD:refs/heads/"+branch); err != nil {
+		return nil, err
+	}
+	res.Pushed = true
+	return res, nil
+}
+
+// merge brings theirs into HEAD. Fast-forwards are taken as is; otherwise
+// git merges the files and the meta file is replaced by an entry-by-entry
+// merge. Any other conflict aborts the merge.
+func (r *Repo) merge(theirs, label string, res *Result) error {
+	if !r.exists("HEAD") {
+		res.Pulled = r.count(theirs)
+		_, err := r.git("merge", "--ff-only", theirs)
+		return err
+	}
+
+	base, err := r.git("merge-base", "HEAD", theirs)
+	unrelated := err != nil
+	if !unrelated && base == r.revision(theirs) {
+		return nil
+	}
+	res.Pulled = r.count("HEAD.." + theirs)
+	if !unrelated && base == r.revision("HEAD") {
+		_, err := r.git("merge", "--ff-only", theirs)
+		return err
+	}
+
+	args := []string{"merge", "--no-ff", "--no-commit"}
+	if unrelated {
+		args = append(args, "--allow-unrelated-histories")
+	}
+	// Conflicts are expected here and resolved below, but the merge must
+	// have started
+	if _, err := r.git(append(args, theirs)...); err != nil && !r.exists("MERGE_HEAD") {
+		return err
+	}
+
+	var baseMeta []byte
+	if !unrelated {
+		baseMeta = r.show(base)
+	}
+	merged, conflicts, err := store.MergeMeta(baseMeta, r.show("HEAD"), r.show(theirs))
+	if err != nil {
+		r.git("merge", "--abort")
+		return err
+	}
+	res.Conflicts = conflicts
+	if err := os.WriteFile(filepath.Join(r.Dir, store.MetaFile), append(merged, '\n'), 0644); err != nil {
+		r.git("merge", "--abort")
+		return fmt.Errorf("failed to write merged meta file: %w", err)
+	}
+	if _, err := r.git("add", store.MetaFile); err != nil {
+		r.git("merge", "--abort")
+		return err
+	}
+
+	if unmerged, _ := r.git("diff", "--name-only", "--diff-filter=U"); unmerged != "" {
+		r.git("merge", "--abort")
+		return fmt.Errorf("merge conflict in %s; resolve it in %s with git and run 'bl sync' again",
+			strings.Join(strings.Split(unmerged, "\n"), ", "), r.Dir)
+	}
+
+	_, err = r.git("commit", "-m", "Merge "+label)
+	return err
+}
+
+// show returns the meta file at rev, or nil if it has none
+func (r *Repo) show(rev string) []byte {
+	out, err := r.git("show", rev+":"+store.MetaFile)
+	if err != nil {
+		return nil
+	}
+	return []byte(out)
+}
+
+func (r *Repo) exists(rev string) bool {
+	_, err := r.git("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	return err == nil
+}
+
+func (r *Repo) revision(rev string) string {
+	out, _ := r.git("rev-parse", rev)
+	return out
+}
+
+func (r *Repo) count(revs string) int {
+	out, err := r.git("rev-list", "--count", revs)
+	if err != nil {
+		return 0
+	}
+	var n int
+	fmt.Sscan(out, &n)
+	return n
+}
+
+// clean reports whether the index has no staged changes
+func (r *Repo) clean() bool {
+	_, err := r.git("diff", "--cached", "--quiet")
+	return err == nil
+}
+
+// git runs a git command in the repo and returns its trimmed output
+func (r *Repo) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.Dir}, args...)...)
+	cmd.Env = append(os.Environ(), r.env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return strings.TrimSpace(stdout.String()), fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func sameDir(a, b string) bool {
+	ia, errA := os.Stat(a)
+	ib, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(ia, ib)
+}
@@ -0,0 +1,136 @@
+package gitsync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+)
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newClone clones the bare remote into a fresh store directory
+func newClone(t *testing.T, remote string) (*Repo, *store.Store) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "store")
+	run(t, filepath.Dir(dir), "clone", "-q", remote, dir)
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := store.NewStore(dir)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return r, st
+}
+
+func addSnippet(t *testing.T, st *store.Store, fullName, content string) {
+	t.Helper()
+	path := filepath.Join(st.Root(), "snippets", "js", fullName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := store.NewSnippetEntry(fullName, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddSnippet(entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sync(t *testing.T, r *Repo, st *store.Store) *Result {
+	t.Helper()
+	var res *Result
+	err := st.WithLock(func() (err error) {
+		res, err = r.Sync("origin", "main")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	return res
+}
+
+func TestSyncMergesMetaEntries(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	run(t, filepath.Dir(remote), "init", "-q", "--bare", "--initial-branch=main", remote)
+
+	a, storeA := newClone(t, remote)
+	addSnippet(t, storeA, "logger@1.0.0.js", "v1\n")
+	if res := sync(t, a, storeA); !res.Committed || !res.Pushed {
+		t.Fatalf("first sync = %+v, want commit and push", res)
+	}
+	if msg := run(t, a.Dir, "log", "-1", "--format=%s"); msg != "Add logger@1.0.0.js" {
+		t.Fatalf("generated message = %q", msg)
+	}
+
+	b, storeB := newClone(t, remote)
+	if !storeB.SnippetExists("logger@1.0.0.js") {
+		t.Fatal("clone is missing the synced snippet")
+	}
+
+	// Both sides add a version and tag it, then sync
+	addSnippet(t, storeA, "logger@1.1.0.js", "a\n")
+	if err := storeA.SetTag("logger.js", "stable", "1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	addSnippet(t, storeB, "util@1.0.0.js", "b\n")
+	if err := storeB.SetTag("logger.js", "beta", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	sync(t, a, storeA)
+	if res := sync(t, b, storeB); res.Pulled != 1 || !res.Pushed || len(res.Conflicts) != 0 {
+		t.Fatalf("merging sync = %+v", res)
+	}
+	sync(t, a, storeA)
+
+	for name, st := range map[string]*store.Store{"a": storeA, "b": storeB} {
+		for _, full := range []string{"logger@1.0.0.js", "logger@1.1.0.js", "util@1.0.0.js"} {
+			if !st.SnippetExists(full) {
+				t.Errorf("store %s is missing %s", name, full)
+			}
+		}
+		tags := st.Tags("logger.js")
+		if tags["stable"] != "1.1.0" || tags["beta"] != "1.0.0" {
+			t.Errorf("store %s tags = %v", name, tags)
+		}
+	}
+
+	// Removal on one side is kept
+	if err := storeA.RemoveSnippet("util@1.0.0.js"); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(a.Dir, "snippets", "js", "util@1.0.0.js"))
+	sync(t, a, storeA)
+	sync(t, b, storeB)
+	if storeB.SnippetExists("util@1.0.0.js") {
+		t.Fatal("removed snippet came back")
+	}
+	if status := run(t, b.Dir, "status", "--porcelain"); status != "" {
+		t.Fatalf("working copy not clean after sync:\n%s", status)
+	}
+}
@@ -0,0 +1,167 @@
+// Package lockfile reads and writes boiler.lock.json, the project's record
+// of which snippet and stack versions were added, where their files went and
+// which variable values they were rendered with. Paths in the lock file are
+// slash-separated and relative to the directory holding it, so it can be
+// checked in and replayed in another checkout.
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+)
+
+const (
+	// FileName is the name of the lock file in a project
+	FileName = "boiler.lock.json"
+	// FormatVersion is the lock file format written by this version
+	FormatVersion = 1
+
+	KindSnippet = "snippet"
+	KindStack   = "stack"
+)
+
+// Lockfile is a loaded boiler.lock.json
+type Lockfile struct {
+	Version   int     `json:"lockfileVersion"`
+	Resources []Entry `json:"resources"`
+
+	path string
+}
+
+// Entry records one resource added to the project
+type Entry struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Extension string `json:"extension,omitempty"`
+	// Checksum is the store checksum of the version that was added
+	Checksum string `json:"checksum"`
+	// Path is the snippet file or stack folder it was added to
+	Path string `json:"path"`
+	// Files lists every file that was written
+	Files []string `json:"files"`
+	// Variables holds the values the template variables were rendered with
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// FullName returns the versioned name, e.g. logger@1.0.0.js
+func (e Entry) FullName() string {
+	return store.FormatResourceName(e.Name, e.Version, e.Extension)
+}
+
+// Key returns the resource key, e.g. logger.js
+func (e Entry) Key() string {
+	return store.ResourceKey(e.Name, e.Extension)
+}
+
+// Find walks up from dir looking for a lock file and returns its path
+func Find(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		candidate := filepath.Join(dir, FileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Load reads the lock file at path. A missing file gives an empty lock
+// file that is created by Save.
+func Load(path string) (*Lockfile, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &Lockfile{Version: FormatVersion, path: abs}
+
+	data, err := os.ReadFile(abs)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", abs, err)
+	}
+	if l.Version > FormatVersion {
+		return nil, fmt.Errorf("%s uses format %d; update Boiler with 'bl self update'", abs, l.Version)
+	}
+	return l, nil
+}
+
+// Path returns the location of the lock file
+func (l *Lockfile) Path() string {
+	return l.path
+}
+
+// Dir returns the directory all paths in the lock file are relative to
+func (l *Lockfile) Dir() string {
+	return filepath.Dir(l.path)
+}
+
+// Rel returns path relative to the lock file's directory. Paths outside of
+// it cannot be recorded and are an error.
+func (l *Lockfile) Rel(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(l.Dir(), abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of %s", path, l.Dir())
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Abs returns the absolute path of a path recorded in the lock file
+func (l *Lockfile) Abs(rel string) string {
+	return filepath.Join(l.Dir(), filepath.FromSlash(rel))
+}
+
+// Put records e, replacing the entry for the same resource at the same path
+func (l *Lockfile) Put(e Entry) {
+	for i, existing := range l.Resources {
+		if existing.Kind == e.Kind && existing.Path == e.Path && existing.Key() == e.Key() {
+			l.Resources[i] = e
+			return
+		}
+	}
+	l.Resources = append(l.Resources, e)
+}
+
+// Save writes the lock file. Entries are sorted by path so the file diffs
+// cleanly.
+func (l *Lockfile) Save() error {
+	sort.SliceStable(l.Resources, func(i, j int) bool {
+		if l.Resources[i].Path != l.Resources[j].Path {
+			return l.Resources[i].Path < l.Resources[j].Path
+		}
+		return l.Resources[i].Key() < l.Resources[j].Key()
+	})
+	l.Version = FormatVersion
+
+	data, err := json.MarshalIndent(l, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", FileName, err)
+	}
+	// An interrupted add or update must not leave a truncated lock file
+	if err := store.WriteFileAtomic(l.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", l.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPutSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "src", "utils")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Load(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := l.Rel(filepath.Join(sub, "logger.js"))
+	if err != nil || rel != "src/utils/logger.js" {
+		t.Fatalf("Rel() = %q, %v", rel, err)
+	}
+	if _, err := l.Rel(filepath.Dir(dir)); err == nil {
+		t.Fatal("Rel() accepted a path outside of the project")
+	}
+
+	l.Put(Entry{Kind: KindSnippet, Name: "logger", Version: "1.0.0", Extension: ".js", Path: rel, Files: []string{rel}})
+	l.Put(Entry{Kind: KindStack, Name: "express", Version: "1.0.0", Path: "api", Files: []string{"api/app.js"}})
+	// Adding another version to the same place replaces the entry
+	l.Put(Entry{Kind: KindSnippet, Name: "logger", Version: "2.0.0", Extension: ".js", Path: rel, Files: []string{rel},
+		Variables: map[string]string{"bl__LEVEL": "debug"}})
+	if err := l.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// The atomic write leaves no temp file behind
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "."+FileName+".*.tmp")); len(leftovers) > 0 {
+		t.Fatalf("temp files left behind: %v", leftovers)
+	}
+
+	path, ok := Find(sub)
+	if !ok || path != filepath.Join(dir, FileName) {
+		t.Fatalf("Find() = %q, %v", path, ok)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Resources) != 2 {
+		t.Fatalf("got %d entries, want 2", len(loaded.Resources))
+	}
+	if e := loaded.Resources[0]; e.Path != "api" {
+		t.Fatalf("entries not sorted by path: %+v", loaded.Resources)
+	}
+	e := loaded.Resources[1]
+	if e.FullName() != "logger@2.0.0.js" || e.Variables["bl__LEVEL"] != "debug" {
+		t.Fatalf("replaced entry = %+v", e)
+	}
+	if loaded.Abs(e.Path) != filepath.Join(sub, "logger.js") {
+		t.Fatalf("Abs() = %s", loaded.Abs(e.Path))
+	}
+}
@@ -40,6 +40,20 @@ func ParseStackConfig(dirPath string) (*StackConfig, error) {
 	return &config, nil
 }
 
+// SaveStackConfig writes boiler.stack.json into a directory
+func SaveStackConfig(dirPath string, config *StackConfig) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dirPath, "boiler.stack.json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
+
 // ResolveIgnorePatterns returns ignore patterns from config
 func ResolveIgnorePatterns(config *StackConfig) []string {
 	// Use patterns from config, always add boiler.stack.json
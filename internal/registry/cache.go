@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rishiyaduwanshi/boiler/internal/archive"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+)
+
+// Cache keeps fetched resources in a store of its own, so they can be added
+// with the normal add flow. Downloads are kept by checksum and only fetched
+// again when the registry publishes different content.
+type Cache struct {
+	dir   string
+	store *store.Store
+}
+
+// checksumRe matches a SHA-256 checksum in hex
+var checksumRe = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// OpenCache opens or creates the cache in dir
+func OpenCache(dir string) (*Cache, error) {
+	st := store.NewStore(dir)
+	if err := st.Load(); err != nil {
+		return nil, fmt.Errorf("failed to open registry cache: %w", err)
+	}
+	return &Cache{dir: dir, store: st}, nil
+}
+
+// Store returns the store holding the cached resources
+func (c *Cache) Store() *store.Store {
+	return c.store
+}
+
+// Fetch makes r available in the cache store and returns its full name.
+// The download is verified before anything is written. It is then unpacked
+// into a staging directory and check is called with the content digest of
+// that copy, e.g. to verify a signature; only if check passes is it moved
+// into the cache store. A nil check accepts every copy.
+func (c *Cache) Fetch(client *Client, r Resource, check func(digest string) error) (string, error) {
+	// Cache paths are built from index fields, so a tampered index must
+	// not be able to point them outside the cache
+	if err := validate(r); err != nil {
+		return "", fmt.Errorf("registry index has an invalid resource: %w", err)
+	}
+	data, err := c.blob(client, r)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache: %w", err)
+	}
+	staging, err := os.MkdirTemp(c.dir, ".staging-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if check == nil {
+		check = func(string) error { return nil }
+	}
+	fullName := r.FullName()
+	if r.Kind == KindStack {
+		return fullName, c.installStack(r, data, staging, check)
+	}
+	return fullName, c.installSnippet(r, data, staging, check)
+}
+
+// blob returns the verified download of r, from disk if cached
+func (c *Cache) blob(client *Client, r Resource) ([]byte, error) {
+	path, err := archive.SafeJoin(c.dir, "blobs/"+strings.ToLower(r.Checksum))
+	if err != nil {
+		return nil, err
+	}
+	if data, err := os.ReadFile(path); err == nil && verify(r, data) == nil {
+		return data, nil
+	}
+
+	data, err := client.Fetch(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write cache: %w", err)
+	}
+	return data, nil
+}
+
+func (c *Cache) installSnippet(r Resource, data []byte, staging string, check func(string) error) error {
+	staged := filepath.Join(staging, "snippet")
+	if err := os.WriteFile(staged, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	entry, err := store.NewSnippetEntry(r.FullName(), staged)
+	if err != nil {
+		return err
+	}
+	if err := check(entry.Checksum); err != nil {
+		return err
+	}
+
+	dest, err := archive.SafeJoin(c.dir, "snippets/"+strings.TrimPrefix(r.Extension, ".")+"/"+r.FullName())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create cache: %w", err)
+	}
+	if err := os.Rename(staged, dest); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	entry.Path = dest
+	entry.Author, entry.Description, entry.Message = r.Author, r.Description, r.Message
+	entry.Signature = r.Signature
+	return c.store.AddSnippet(entry)
+}
+
+func (c *Cache) installStack(r Resource, data []byte, staging string, check func(string) error) error {
+	staged := filepath.Join(staging, "stack")
+	if err := archive.Unpack(bytes.NewReader(data), staged); err != nil {
+		return fmt.Errorf("failed to unpack '%s': %w", r.FullName(), err)
+	}
+	entry, err := store.NewStackEntry(r.FullName(), staged)
+	if err != nil {
+		return err
+	}
+	if err := check(entry.Checksum); err != nil {
+		return err
+	}
+
+	dest, err := archive.SafeJoin(c.dir, "stacks/"+r.FullName())
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create cache: %w", err)
+	}
+	if err := os.Rename(staged, dest); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	entry.Path = dest
+	entry.Author, entry.Description, entry.Message = r.Author, r.Description, r.Message
+	entry.Signature = r.Signature
+	return c.store.AddStack(entry)
+}
+
+// validate rejects resources whose names, version or checksum cannot be
+// used as a single path element, like bundle resources
+func validate(r Resource) error {
+	if r.Kind != KindSnippet && r.Kind != KindStack {
+		return fmt.Errorf("unknown kind '%s'", r.Kind)
+	}
+	if !nameRe.MatchString(r.Name) || strings.Contains(r.Name, "@") {
+		return fmt.Errorf("invalid name '%s'", r.Name)
+	}
+	if _, err := store.ParseVersion(r.Version); err != nil {
+		return fmt.Errorf("invalid version for '%s': %w", r.Name, err)
+	}
+	switch {
+	case r.Kind == KindSnippet && (!strings.HasPrefix(r.Extension, ".") || !nameRe.MatchString(strings.TrimPrefix(r.Extension, "."))):
+		return fmt.Errorf("invalid extension for '%s'", r.Name)
+	case r.Kind == KindStack && r.Extension != "":
+		return fmt.Errorf("stack '%s' has an extension", r.Name)
+	}
+	if !checksumRe.MatchString(r.Checksum) {
+		return fmt.Errorf("invalid checksum for '%s'", r.Name)
+	}
+	return nil
+}
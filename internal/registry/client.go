@@ -0,0 +1,166 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxDownload caps the size of a single snippet or stack download
+const maxDownload = 100 << 20
+
+// Client talks to a registry over HTTP
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for the registry at baseURL
+func NewClient(baseURL string) (*Client, error) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return nil, fmt.Errorf("no registry configured. Set 'registry' in boiler.conf.json to the URL of a Boiler registry, e.g. one run with 'bl registry serve'")
+	}
+	if u, err := url.Parse(baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid registry URL '%s'", baseURL)
+	}
+
+	return &Client{
+		BaseURL: baseURL,
+		HTTP:    &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Index fetches the registry index
+func (c *Client) Index() (*Index, error) {
+	var idx Index
+	if err := c.getJSON(PathIndex, &idx); err != nil {
+		return nil, err
+	}
+	return &idx, nil
+}
+
+// Search returns resources matching query. kind may be empty, KindSnippet
+// or KindStack.
+func (c *Client) Search(query, kind string) ([]Resource, error) {
+	params := url.Values{"q": {query}}
+	if kind != "" {
+		params.Set("kind", kind)
+	}
+
+	var resp SearchResponse
+	if err := c.getJSON(PathSearch+"?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// Fetch downloads a resource and verifies it against the checksum in r
+func (c *Client) Fetch(r Resource) ([]byte, error) {
+	path := PathSnippets
+	if r.Kind == KindStack {
+		path = PathStacks
+	}
+
+	resp, err := c.do(http.MethodGet, path+url.PathEscape(r.FullName()), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownload+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download '%s': %w", r.FullName(), err)
+	}
+	if len(data) > maxDownload {
+		return nil, fmt.Errorf("'%s' is larger than %d MB", r.FullName(), maxDownload>>20)
+	}
+
+	if err := verify(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Publish uploads a new version. The checksum is filled in from Content.
+func (c *Client) Publish(req PublishRequest) (*Resource, error) {
+	req.Checksum = Checksum(req.Content)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode publish request: %w", err)
+	}
+
+	resp, err := c.do(http.MethodPost, PathPublish, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var r Resource
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid publish response: %w", err)
+	}
+	return &r, nil
+}
+
+func (c *Client) getJSON(path string, v any) error {
+	resp, err := c.do(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid registry response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// do sends a request and turns non-2xx answers into *Error
+func (c *Client) do(method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registry unreachable: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		var e ErrorResponse
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			e.Error = http.StatusText(resp.StatusCode)
+		}
+		return nil, &Error{Status: resp.StatusCode, Message: e.Error}
+	}
+
+	return resp, nil
+}
+
+func verify(r Resource, data []byte) error {
+	if r.Checksum == "" {
+		return fmt.Errorf("registry sent no checksum for '%s'", r.FullName())
+	}
+	if got := Checksum(data); !strings.EqualFold(got, r.Checksum) {
+		return fmt.Errorf("checksum mismatch for '%s': expected %s, got %s", r.FullName(), r.Checksum, got)
+	}
+	return nil
+}
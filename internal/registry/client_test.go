@@ -0,0 +1,322 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/archive"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+)
+
+// fakeRegistry serves a fixed index and downloads over the registry protocol
+type fakeRegistry struct {
+	index     Index
+	downloads map[string][]byte
+	token     string
+	published []PublishRequest
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == PathIndex:
+		json.NewEncoder(w).Encode(f.index)
+	case r.URL.Path == PathSearch:
+		var results []Resource
+		for _, res := range f.index.Resources {
+			if strings.Contains(res.Name, r.URL.Query().Get("q")) &&
+				(r.URL.Query().Get("kind") == "" || r.URL.Query().Get("kind") == res.Kind) {
+				results = append(results, res)
+			}
+		}
+		json.NewEncoder(w).Encode(SearchResponse{Results: results})
+	case strings.HasPrefix(r.URL.Path, PathSnippets), strings.HasPrefix(r.URL.Path, PathStacks):
+		data, ok := f.downloads[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "not found"})
+			return
+		}
+		w.Write(data)
+	case r.URL.Path == PathPublish && r.Method == http.MethodPost:
+		if r.Header.Get("Authorization") != "Bearer "+f.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid token"})
+			return
+		}
+		var req PublishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.published = append(f.published, req)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Resource{Kind: req.Kind, Name: req.Name, Version: "1.0.0", Extension: req.Extension, Checksum: req.Checksum})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, *Client) {
+	t.Helper()
+
+	snippet := []byte("export const log = console.log\n")
+
+	stackDir := t.TempDir()
+	os.MkdirAll(filepath.Join(stackDir, "src"), 0755)
+	os.WriteFile(filepath.Join(stackDir, "src", "app.js"), []byte("app()\n"), 0644)
+	os.WriteFile(filepath.Join(stackDir, "boiler.stack.json"), []byte(`{"id":"express","version":"1.0.0"}`), 0644)
+	var stack bytes.Buffer
+	if err := archive.Pack(&stack, stackDir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeRegistry{
+		token: "secret",
+		index: Index{
+			Resources: []Resource{
+				{Kind: KindSnippet, Name: "logger", Version: "1.0.0", Extension: ".js", Checksum: Checksum([]byte("old"))},
+				{Kind: KindSnippet, Name: "logger", Version: "1.2.0", Extension: ".js", Author: "Ann", Checksum: Checksum(snippet)},
+				{Kind: KindSnippet, Name: "logger", Version: "2.0.0", Extension: ".js", Checksum: Checksum([]byte("tampered"))},
+				{Kind: KindStack, Name: "express", Version: "1.0.0", Checksum: Checksum(stack.Bytes())},
+			},
+			Tags: map[string]map[string]string{"logger.js": {"stable": "1.2.0"}},
+		},
+		downloads: map[string][]byte{
+			PathSnippets + "logger@1.2.0.js": snippet,
+			PathSnippets + "logger@2.0.0.js": []byte("evil"),
+			PathStacks + "express@1.0.0":     stack.Bytes(),
+		},
+	}
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, client
+}
+
+func TestIndexResolve(t *testing.T) {
+	_, client := newFakeRegistry(t)
+
+	idx, err := client.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, spec, ext string
+		want            string
+	}{
+		{"logger", "", ".js", "logger@2.0.0.js"},
+		{"logger", "^1", ".js", "logger@1.2.0.js"},
+		{"logger", "stable", ".js", "logger@1.2.0.js"},
+		{"logger", "", "", "logger@2.0.0.js"},
+		{"express", "", "", "express@1.0.0"},
+	}
+	for _, tt := range tests {
+		r, err := idx.Resolve(tt.name, tt.spec, tt.ext)
+		if err != nil {
+			t.Errorf("Resolve(%q, %q, %q): %v", tt.name, tt.spec, tt.ext, err)
+			continue
+		}
+		if r.FullName() != tt.want {
+			t.Errorf("Resolve(%q, %q, %q) = %s, want %s", tt.name, tt.spec, tt.ext, r.FullName(), tt.want)
+		}
+	}
+
+	if _, err := idx.Resolve("missing", "", ""); err == nil {
+		t.Error("Resolve(missing) succeeded, want error")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	_, client := newFakeRegistry(t)
+
+	results, err := client.Search("express", KindStack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].FullName() != "express@1.0.0" {
+		t.Fatalf("Search() = %v, want express@1.0.0", results)
+	}
+}
+
+func TestCacheFetchVerifiesChecksum(t *testing.T) {
+	_, client := newFakeRegistry(t)
+	idx, err := client.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	good, _ := idx.Get(KindSnippet, "logger@1.2.0.js")
+	name, err := cache.Fetch(client, good, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := cache.Store().GetSnippet(name)
+	if !ok || entry.Author != "Ann" {
+		t.Fatalf("cached entry = %+v, want author Ann", entry)
+	}
+	if data, _ := os.ReadFile(entry.Path); string(data) != "export const log = console.log\n" {
+		t.Fatalf("cached snippet = %q", data)
+	}
+
+	bad, _ := idx.Get(KindSnippet, "logger@2.0.0.js")
+	if _, err := cache.Fetch(client, bad, nil); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Fetch(tampered) error = %v, want checksum mismatch", err)
+	}
+	if cache.Store().SnippetExists("logger@2.0.0.js") {
+		t.Fatal("tampered snippet was cached")
+	}
+
+	stack, _ := idx.Get(KindStack, "express@1.0.0")
+	name, err = cache.Fetch(client, stack, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stackEntry, _ := cache.Store().GetStack(name)
+	if _, err := os.Stat(filepath.Join(stackEntry.Path, "src", "app.js")); err != nil {
+		t.Fatalf("stack not unpacked: %v", err)
+	}
+}
+
+func TestCacheFetchStagesUntilChecked(t *testing.T) {
+	_, client := newFakeRegistry(t)
+	idx, err := client.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cache, err := OpenCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reject := func(string) error { return errors.New("unsigned") }
+
+	snippet, _ := idx.Get(KindSnippet, "logger@1.2.0.js")
+	stack, _ := idx.Get(KindStack, "express@1.0.0")
+	for _, r := range []Resource{snippet, stack} {
+		if _, err := cache.Fetch(client, r, reject); err == nil || err.Error() != "unsigned" {
+			t.Fatalf("Fetch(%s) error = %v, want the check's error", r.FullName(), err)
+		}
+	}
+	if cache.Store().SnippetExists(snippet.FullName()) || cache.Store().StackExists(stack.FullName()) {
+		t.Fatal("rejected resources were cached")
+	}
+	for _, pattern := range []string{"snippets/*/*", "stacks/*", ".staging-*"} {
+		if leftovers, _ := filepath.Glob(filepath.Join(dir, pattern)); len(leftovers) > 0 {
+			t.Fatalf("rejected fetch left %v behind", leftovers)
+		}
+	}
+
+	// The check sees the digest the cached entry records
+	var checked string
+	name, err := cache.Fetch(client, stack, func(digest string) error {
+		checked = digest
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := cache.Store().GetStack(name)
+	if !ok || entry.Checksum != checked {
+		t.Fatalf("cached entry = %+v, want checksum %s", entry, checked)
+	}
+	if err := store.VerifyStack(entry); err != nil {
+		t.Fatalf("VerifyStack() error = %v", err)
+	}
+}
+
+func TestCacheFetchRejectsUnsafeResources(t *testing.T) {
+	_, client := newFakeRegistry(t)
+	idx, err := client.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	dir := filepath.Join(root, "cache")
+	cache, err := OpenCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, _ := idx.Get(KindSnippet, "logger@1.2.0.js")
+	stack, _ := idx.Get(KindStack, "express@1.0.0")
+	tests := []struct {
+		name string
+		edit func(r *Resource)
+		base Resource
+	}{
+		{"version", func(r *Resource) { r.Version = "1.0.0/../../../escaped" }, snippet},
+		{"extension", func(r *Resource) { r.Extension = "./../../escaped" }, snippet},
+		{"name", func(r *Resource) { r.Name = "../escaped" }, stack},
+		{"stack version", func(r *Resource) { r.Version = "../.." }, stack},
+		{"checksum", func(r *Resource) { r.Checksum = "../../escaped" }, snippet},
+		{"short checksum", func(r *Resource) { r.Checksum = "abc123" }, snippet},
+	}
+	for _, tt := range tests {
+		r := tt.base
+		tt.edit(&r)
+		if _, err := cache.Fetch(client, r, nil); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("Fetch() with a tampered %s error = %v, want invalid resource", tt.name, err)
+		}
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "cache" {
+			t.Errorf("tampered resource wrote %s outside the cache", e.Name())
+		}
+	}
+}
+
+func TestPublishNeedsToken(t *testing.T) {
+	f, client := newFakeRegistry(t)
+	req := PublishRequest{Kind: KindSnippet, Name: "util", Extension: ".go", Content: []byte("// __author Ann\npackage util\n")}
+
+	_, err := client.Publish(req)
+	var regErr *Error
+	if !errors.As(err, &regErr) || regErr.Status != http.StatusUnauthorized {
+		t.Fatalf("Publish() without token error = %v, want HTTP 401", err)
+	}
+
+	client.Token = "secret"
+	r, err := client.Publish(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.FullName() != "util@1.0.0.go" {
+		t.Fatalf("Publish() = %s, want util@1.0.0.go", r.FullName())
+	}
+	if len(f.published) != 1 || f.published[0].Checksum != Checksum(req.Content) {
+		t.Fatalf("server got %+v, want content with checksum", f.published)
+	}
+}
+
+func TestNewClientNeedsRegistry(t *testing.T) {
+	for _, url := range []string{"", "  ", "ftp://example.com"} {
+		if _, err := NewClient(url); err == nil {
+			t.Errorf("NewClient(%q) succeeded", url)
+		}
+	}
+	if _, err := NewClient(""); err == nil || !strings.Contains(err.Error(), "no registry configured") {
+		t.Errorf("NewClient(\"\") error = %v, want 'no registry configured'", err)
+	}
+}
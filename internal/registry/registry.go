@@ -0,0 +1,251 @@
+// Package registry implements the HTTP/JSON protocol used to share
+// snippets and stacks through a remote registry.
+//
+// All endpoints live below the registry base URL (Config.Registry) and
+// exchange JSON unless noted otherwise:
+//
+//	GET  /v1/index                     Index of every published version
+//	GET  /v1/search?q=<text>&kind=<k>  {"results": [Resource...]}; kind is
+//	                                   optional and one of snippet, stack
+//	GET  /v1/snippets/<full name>      Raw snippet file, e.g. logger@1.0.0.js
+//	GET  /v1/stacks/<full name>        Stack folder as tar.gz, e.g. express@1.0.0
+//	POST /v1/publish                   PublishRequest; needs
+//	                                   "Authorization: Bearer <token>" and
+//	                                   answers 201 with the new Resource
+//
+// Every Resource carries the SHA-256 of its download (the snippet file or
+// the stack archive) and clients refuse content that does not match.
+// Publishing follows the store's versioning rules: a snippet without a
+// version gets the next version of the published ones (1.0.0 first, then
+// bumped by the requested part, patch by default); a stack must name its
+// version. Publishing a version that already exists fails with 409.
+//
+// A resource may carry an ed25519 signature over its kind, full name and
+// content digest (see signing.ResourceMessage), so signed uploads must name
+// their version. The content digest is the checksum the store keeps: the
+// SHA-256 of a snippet file, or store.DirChecksum of an unpacked stack, so
+// it stays the same however the stack is packed. The registry rejects
+// signatures that do not match; whether the signer is trusted is up to the
+// client.
+//
+// Errors are answered with a non-2xx status and {"error": "<message>"}.
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rishiyaduwanshi/boiler/internal/archive"
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+)
+
+// Resource kinds
+const (
+	KindSnippet = "snippet"
+	KindStack   = "stack"
+)
+
+// Protocol paths, relative to the registry base URL
+const (
+	PathIndex    = "/v1/index"
+	PathSearch   = "/v1/search"
+	PathSnippets = "/v1/snippets/"
+	PathStacks   = "/v1/stacks/"
+	PathPublish  = "/v1/publish"
+)
+
+// Resource describes one published version of a snippet or stack
+type Resource struct {
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Extension   string    `json:"extension,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	PublishedAt time.Time `json:"publishedAt"`
+	// Signature is set for resources published with a signing key
+	Signature *signing.Signature `json:"signature,omitempty"`
+}
+
+// FullName returns the versioned name, e.g. logger@1.0.0.js or express@1.0.0
+func (r Resource) FullName() string {
+	return store.FormatResourceName(r.Name, r.Version, r.Extension)
+}
+
+// Key identifies all versions of the resource, e.g. logger.js or express
+func (r Resource) Key() string {
+	return store.ResourceKey(r.Name, r.Extension)
+}
+
+// Index lists every published version and the registry's tags, keyed like
+// the store's tags by resource key
+type Index struct {
+	Resources []Resource                   `json:"resources"`
+	Tags      map[string]map[string]string `json:"tags,omitempty"`
+}
+
+// SearchResponse is the body of a search answer
+type SearchResponse struct {
+	Results []Resource `json:"results"`
+}
+
+// PublishRequest is the body of a publish call. Content holds the snippet
+// file or the stack tar.gz and is base64 encoded in JSON.
+type PublishRequest struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	Bump        string `json:"bump,omitempty"`
+	Extension   string `json:"extension,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Description string `json:"description,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Checksum    string `json:"checksum"`
+	Content     []byte `json:"content"`
+	// Signature is optional; see ContentDigest for what it covers
+	Signature *signing.Signature `json:"signature,omitempty"`
+}
+
+// ErrorResponse is the body of every error answer
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Error is returned by the client for non-2xx answers
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("registry: %s (HTTP %d)", e.Message, e.Status)
+}
+
+// Checksum returns the hex SHA-256 of data, as used in Resource.Checksum
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ContentDigest returns the checksum the store keeps for published content:
+// the SHA-256 of a snippet, or the directory checksum of an unpacked stack
+// archive. Resource signatures are made over it.
+func ContentDigest(kind string, content []byte) (string, error) {
+	if kind != KindStack {
+		return Checksum(content), nil
+	}
+
+	dir, err := os.MkdirTemp("", "boiler-digest-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := archive.Unpack(bytes.NewReader(content), dir); err != nil {
+		return "", err
+	}
+	return store.DirChecksum(dir)
+}
+
+// Versions returns the published versions of a resource, sorted ascending
+func (idx *Index) Versions(kind, name, ext string) []string {
+	var versions []string
+	for _, r := range idx.Resources {
+		if r.Kind == kind && r.Name == name && r.Extension == ext {
+			versions = append(versions, r.Version)
+		}
+	}
+	store.SortVersions(versions)
+	return versions
+}
+
+// NextVersion returns the version a new upload of a resource gets when it
+// names none: 1.0.0 first, then the latest published version bumped by
+// bump, like store.GetNextVersion
+func (idx *Index) NextVersion(kind, name, ext, bump string) (string, error) {
+	if _, err := (store.Version{}).Bump(bump); err != nil {
+		return "", err
+	}
+
+	versions := idx.Versions(kind, name, ext)
+	if len(versions) == 0 {
+		return store.Version{Major: 1}.String(), nil
+	}
+	latest, err := store.ParseVersion(versions[len(versions)-1])
+	if err != nil {
+		return "", err
+	}
+	next, err := latest.Bump(bump)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
+
+// Get returns a resource by kind and full name
+func (idx *Index) Get(kind, fullName string) (Resource, bool) {
+	for _, r := range idx.Resources {
+		if r.Kind == kind && r.FullName() == fullName {
+			return r, true
+		}
+	}
+	return Resource{}, false
+}
+
+// Resolve picks the published version matching spec the same way the local
+// store does: exact versions, constraints such as ^2, tags, or the latest
+// version when spec is empty. Without an extension a stack is preferred,
+// then a snippet if only one extension exists for the name.
+func (idx *Index) Resolve(name, spec, ext string) (Resource, error) {
+	kind := KindSnippet
+	if ext == "" {
+		if len(idx.Versions(KindStack, name, "")) > 0 {
+			kind = KindStack
+		} else {
+			exts := idx.extensions(name)
+			switch len(exts) {
+			case 0:
+				return Resource{}, fmt.Errorf("'%s' not found in registry", name)
+			case 1:
+				ext = exts[0]
+			default:
+				return Resource{}, fmt.Errorf("'%s' exists in the registry as %v. Add the extension", name, exts)
+			}
+		}
+	}
+
+	versions := idx.Versions(kind, name, ext)
+	if len(versions) == 0 {
+		return Resource{}, fmt.Errorf("'%s' not found in registry", store.ResourceKey(name, ext))
+	}
+
+	key := store.ResourceKey(name, ext)
+	version, err := store.ResolveVersion(versions, idx.Tags[key], spec)
+	if err != nil {
+		return Resource{}, fmt.Errorf("%s '%s': %w", kind, key, err)
+	}
+
+	r, _ := idx.Get(kind, store.FormatResourceName(name, version, ext))
+	return r, nil
+}
+
+func (idx *Index) extensions(name string) []string {
+	seen := make(map[string]bool)
+	var exts []string
+	for _, r := range idx.Resources {
+		if r.Kind == KindSnippet && r.Name == name && !seen[r.Extension] {
+			seen[r.Extension] = true
+			exts = append(exts, r.Extension)
+		}
+	}
+	return exts
+}
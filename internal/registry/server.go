@@ -0,0 +1,595 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rishiyaduwanshi/boiler/internal/archive"
+	"github.com/rishiyaduwanshi/boiler/internal/models"
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
+	"github.com/rishiyaduwanshi/boiler/internal/store"
+	"github.com/rishiyaduwanshi/boiler/internal/utils"
+)
+
+// Token access levels
+const (
+	AccessRead  = "read"
+	AccessWrite = "write"
+)
+
+// maxPublish caps the size of a publish request body (base64 content)
+const maxPublish = maxDownload * 4 / 3
+
+// stackArchiveIgnore is left out of stack archives
+var stackArchiveIgnore = []string{"node_modules", ".git", ".DS_Store", "Thumbs.db"}
+
+// nameRe restricts published names to what the store can hold as a file name
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ServerConfig is the registry server's config file
+type ServerConfig struct {
+	// Public allows reading without a token
+	Public bool    `json:"public"`
+	Tokens []Token `json:"tokens"`
+}
+
+// Token is a bearer token with read or write access. Write includes read.
+type Token struct {
+	Name   string `json:"name"`
+	Token  string `json:"token"`
+	Access string `json:"access"`
+}
+
+// LoadServerConfig reads a server config file
+func LoadServerConfig(path string) (*ServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var conf ServerConfig
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, t := range conf.Tokens {
+		if t.Token == "" {
+			return nil, fmt.Errorf("token '%s' in %s is empty", t.Name, path)
+		}
+		if t.Access != AccessRead && t.Access != AccessWrite {
+			return nil, fmt.Errorf("token '%s' in %s: access must be '%s' or '%s'", t.Name, path, AccessRead, AccessWrite)
+		}
+	}
+	return &conf, nil
+}
+
+// Server exposes a store directory as a registry
+type Server struct {
+	root        string
+	snippetsDir string
+	stacksDir   string
+	conf        *ServerConfig
+
+	// Logf, if set, receives one line per request
+	Logf func(format string, args ...any)
+
+	// Stack archives by full name, rebuilt when the stored stack changes
+	mu       sync.Mutex
+	archives map[string]cachedArchive
+
+	// publishMu serializes publishes so two uploads never get one version
+	publishMu sync.Mutex
+}
+
+type cachedArchive struct {
+	stamp string
+	data  []byte
+}
+
+// NewServer serves the store in root, storing published snippets and stacks
+// below snippetsDir and stacksDir like 'bl store' does
+func NewServer(root, snippetsDir, stacksDir string, conf *ServerConfig) *Server {
+	if conf == nil {
+		conf = &ServerConfig{}
+	}
+	return &Server{
+		root:        root,
+		snippetsDir: snippetsDir,
+		stacksDir:   stacksDir,
+		conf:        conf,
+		archives:    make(map[string]cachedArchive),
+	}
+}
+
+// Handler returns the HTTP handler for the registry protocol
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+PathIndex, s.read(s.handleIndex))
+	mux.HandleFunc("GET "+PathSearch, s.read(s.handleSearch))
+	mux.HandleFunc("GET "+PathSnippets+"{name}", s.read(s.handleSnippet))
+	mux.HandleFunc("GET "+PathStacks+"{name}", s.read(s.handleStack))
+	mux.HandleFunc("POST "+PathPublish, s.write(s.handlePublish))
+	return s.logged(mux)
+}
+
+func (s *Server) logged(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+		if s.Logf != nil {
+			s.Logf("%s %s %d %s", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Millisecond))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// read allows public registries, or any valid token
+func (s *Server) read(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.conf.Public && s.access(r) == "" {
+			writeError(w, http.StatusUnauthorized, "a read token is required")
+			return
+		}
+		h(w, r)
+	}
+}
+
+// write allows write tokens only
+func (s *Server) write(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch s.access(r) {
+		case AccessWrite:
+			h(w, r)
+		case AccessRead:
+			writeError(w, http.StatusForbidden, "token has read access only")
+		default:
+			writeError(w, http.StatusUnauthorized, "a write token is required")
+		}
+	}
+}
+
+// access returns the access level of the request's bearer token, if valid
+func (s *Server) access(r *http.Request) string {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || given == "" {
+		return ""
+	}
+	for _, t := range s.conf.Tokens {
+		if subtle.ConstantTimeCompare([]byte(given), []byte(t.Token)) == 1 {
+			return t.Access
+		}
+	}
+	return ""
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	idx, err := s.index()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, idx)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(r.URL.Query().Get("q"))
+	kind := r.URL.Query().Get("kind")
+
+	idx, err := s.index()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := []Resource{}
+	for _, res := range idx.Resources {
+		if kind != "" && res.Kind != kind {
+			continue
+		}
+		for _, field := range []string{res.FullName(), res.Description, res.Author} {
+			if strings.Contains(strings.ToLower(field), query) {
+				results = append(results, res)
+				break
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, SearchResponse{Results: results})
+}
+
+func (s *Server) handleSnippet(w http.ResponseWriter, r *http.Request) {
+	st, err := s.openStore()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	entry, ok := st.GetSnippet(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("snippet '%s' not found", r.PathValue("name")))
+		return
+	}
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read snippet")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+func (s *Server) handleStack(w http.ResponseWriter, r *http.Request) {
+	st, err := s.openStore()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	entry, ok := st.GetStack(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("stack '%s' not found", r.PathValue("name")))
+		return
+	}
+	data, err := s.stackArchive(entry)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to pack stack")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Write(data)
+}
+
+func (s *Server) handlePublish(w http.ResponseWriter, r *http.Request) {
+	var req PublishRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPublish)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid publish request: "+err.Error())
+		return
+	}
+
+	res, err := s.publish(req)
+	if err != nil {
+		var pubErr *Error
+		if errors.As(err, &pubErr) {
+			writeError(w, pubErr.Status, pubErr.Message)
+		} else {
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	writeJSON(w, http.StatusCreated, res)
+}
+
+// publish validates a request and stores it with the store's versioning
+// rules. Client mistakes are returned as *Error with a 4xx status.
+func (s *Server) publish(req PublishRequest) (*Resource, error) {
+	if !nameRe.MatchString(req.Name) || strings.Contains(req.Name, "@") {
+		return nil, &Error{http.StatusBadRequest, fmt.Sprintf("invalid name '%s'", req.Name)}
+	}
+	if Checksum(req.Content) != strings.ToLower(req.Checksum) {
+		return nil, &Error{http.StatusBadRequest, "checksum does not match content"}
+	}
+
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+	st, err := s.openStore()
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Kind {
+	case KindSnippet:
+		return s.publishSnippet(st, req)
+	case KindStack:
+		return s.publishStack(st, req)
+	default:
+		return nil, &Error{http.StatusBadRequest, fmt.Sprintf("invalid kind '%s'", req.Kind)}
+	}
+}
+
+func (s *Server) publishSnippet(st *store.Store, req PublishRequest) (*Resource, error) {
+	if !strings.HasPrefix(req.Extension, ".") || !nameRe.MatchString(strings.TrimPrefix(req.Extension, ".")) {
+		return nil, &Error{http.StatusBadRequest, "snippets need an extension such as .js"}
+	}
+
+	// Same rules as 'bl store': next version of the latest one unless a
+	// version is given
+	version := strings.TrimSpace(req.Version)
+	if version == "" && req.Signature != nil {
+		// The signature covers the version, so the signer has to pick it
+		return nil, &Error{http.StatusBadRequest, "signed snippets need a version"}
+	}
+	if version == "" {
+		bump := req.Bump
+		if bump == "" {
+			bump = store.BumpPatch
+		}
+		next, err := st.GetNextVersion(req.Name, req.Extension, bump)
+		if err != nil {
+			return nil, &Error{http.StatusBadRequest, err.Error()}
+		}
+		version = next
+	} else {
+		normalized, err := store.NormalizeVersion(version)
+		if err != nil {
+			return nil, &Error{http.StatusBadRequest, err.Error()}
+		}
+		version = normalized
+	}
+
+	fullName := store.FormatResourceName(req.Name, version, req.Extension)
+	if st.SnippetExists(fullName) {
+		return nil, &Error{http.StatusConflict, fmt.Sprintf("snippet '%s' already exists", fullName)}
+	}
+
+	tx, err := st.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	dest := filepath.Join(s.snippetsDir, strings.TrimPrefix(req.Extension, "."), fullName)
+	staged := tx.StagePath(fullName)
+	if err := os.MkdirAll(filepath.Dir(staged), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(staged, req.Content, 0644); err != nil {
+		return nil, err
+	}
+
+	// Same checks as 'bl store'
+	meta, err := utils.ParseSnippetMetadata(staged)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateSnippetMetadata(meta); err != nil {
+		return nil, &Error{http.StatusBadRequest, "invalid snippet metadata: " + err.Error()}
+	}
+	if err := meta.CheckVariables(); err != nil {
+		return nil, &Error{http.StatusBadRequest, err.Error()}
+	}
+
+	if err := tx.Put(staged, dest); err != nil {
+		return nil, err
+	}
+
+	entry, err := store.NewSnippetEntry(fullName, dest)
+	if err != nil {
+		return nil, err
+	}
+	entry.Author, entry.Description, entry.Message = meta.Author, meta.Description, req.Message
+	if req.Description != "" {
+		entry.Description = req.Description
+	}
+	if err := checkSignature(fullName, entry.Checksum, req); err != nil {
+		return nil, err
+	}
+	entry.Signature = req.Signature
+	if err := st.AddSnippet(entry); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	res := snippetResource(entry, Checksum(req.Content))
+	return &res, nil
+}
+
+func (s *Server) publishStack(st *store.Store, req PublishRequest) (*Resource, error) {
+	// Stacks carry their version in boiler.stack.json, like 'bl store'
+	if strings.TrimSpace(req.Version) == "" {
+		return nil, &Error{http.StatusBadRequest, "stacks need a version"}
+	}
+	version, err := store.NormalizeVersion(req.Version)
+	if err != nil {
+		return nil, &Error{http.StatusBadRequest, err.Error()}
+	}
+
+	fullName := store.FormatResourceName(req.Name, version, "")
+	if st.StackExists(fullName) {
+		return nil, &Error{http.StatusConflict, fmt.Sprintf("stack '%s' already exists", fullName)}
+	}
+
+	tx, err := st.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	staged := tx.StagePath(fullName)
+	if err := archive.Unpack(bytes.NewReader(req.Content), staged); err != nil {
+		return nil, &Error{http.StatusBadRequest, err.Error()}
+	}
+	if _, err := os.Stat(filepath.Join(staged, store.StackConfigFile)); err != nil {
+		return nil, &Error{http.StatusBadRequest, "stack archive has no " + store.StackConfigFile}
+	}
+
+	// Same checks as 'bl store', and the config has to name what is published
+	stackConfig, err := models.ParseStackConfig(staged)
+	if err != nil {
+		return nil, &Error{http.StatusBadRequest, err.Error()}
+	}
+	if stackConfig.ID == "" || stackConfig.Version == "" {
+		return nil, &Error{http.StatusBadRequest, "'id' and 'version' are required in " + store.StackConfigFile}
+	}
+	if stackConfig.ID != req.Name {
+		return nil, &Error{http.StatusBadRequest, fmt.Sprintf("%s has id '%s', not '%s'", store.StackConfigFile, stackConfig.ID, req.Name)}
+	}
+	if !store.SameVersion(stackConfig.Version, version) {
+		return nil, &Error{http.StatusBadRequest, fmt.Sprintf("%s has version '%s', not '%s'", store.StackConfigFile, stackConfig.Version, version)}
+	}
+
+	dest := filepath.Join(s.stacksDir, fullName)
+	if err := tx.Put(staged, dest); err != nil {
+		return nil, err
+	}
+
+	entry, err := store.NewStackEntry(fullName, dest)
+	if err != nil {
+		return nil, err
+	}
+	entry.Author, entry.Description, entry.Message = stackConfig.Author, stackConfig.Description, req.Message
+	if req.Description != "" {
+		entry.Description = req.Description
+	}
+	if err := checkSignature(fullName, entry.Checksum, req); err != nil {
+		return nil, err
+	}
+	entry.Signature = req.Signature
+	if err := st.AddStack(entry); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	data, err := s.stackArchive(entry)
+	if err != nil {
+		return nil, err
+	}
+	res := stackResource(entry, Checksum(data))
+	return &res, nil
+}
+
+// checkSignature rejects a signature that does not match the published
+// version and content. Whether the signing key is trusted is for clients
+// to decide.
+func checkSignature(fullName, digest string, req PublishRequest) error {
+	if req.Signature == nil {
+		return nil
+	}
+	message := signing.ResourceMessage(req.Kind, fullName, digest)
+	if err := signing.Check(req.Signature, message); err != nil {
+		return &Error{http.StatusBadRequest, err.Error()}
+	}
+	return nil
+}
+
+// openStore reads the store afresh for every request, so resources stored
+// while the server runs show up and requests share no state
+func (s *Server) openStore() (*store.Store, error) {
+	st := store.NewStore(s.root)
+	if err := st.Load(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// index builds the registry index from the store
+func (s *Server) index() (*Index, error) {
+	st, err := s.openStore()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Index{Resources: []Resource{}, Tags: make(map[string]map[string]string)}
+	for _, entry := range st.SnippetEntries() {
+		sum, err := store.FileChecksum(entry.Path)
+		if err != nil {
+			// Missing files are reported by 'bl fsck', not served
+			continue
+		}
+		idx.Resources = append(idx.Resources, snippetResource(entry, sum))
+		addTags(idx, st, store.ResourceKey(entry.Name, entry.Extension))
+	}
+	for _, entry := range st.StackEntries() {
+		data, err := s.stackArchive(entry)
+		if err != nil {
+			continue
+		}
+		idx.Resources = append(idx.Resources, stackResource(entry, Checksum(data)))
+		addTags(idx, st, store.ResourceKey(entry.Name, ""))
+	}
+	return idx, nil
+}
+
+func addTags(idx *Index, st *store.Store, key string) {
+	if tags := st.Tags(key); len(tags) > 0 {
+		idx.Tags[key] = tags
+	}
+}
+
+// stackArchive returns the tar.gz of a stored stack. Archives are
+// reproducible, so the checksum in the index matches every download.
+func (s *Server) stackArchive(entry *store.StackEntry) ([]byte, error) {
+	stamp := entry.Checksum + "|" + entry.UpdatedAt.String()
+
+	s.mu.Lock()
+	cached, ok := s.archives[entry.FullName()]
+	s.mu.Unlock()
+	if ok && cached.stamp == stamp {
+		return cached.data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := archive.Pack(&buf, entry.Path, stackArchiveIgnore); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.archives[entry.FullName()] = cachedArchive{stamp: stamp, data: buf.Bytes()}
+	s.mu.Unlock()
+	return buf.Bytes(), nil
+}
+
+func snippetResource(e *store.SnippetEntry, checksum string) Resource {
+	return Resource{
+		Kind:        KindSnippet,
+		Name:        e.Name,
+		Version:     e.Version,
+		Extension:   e.Extension,
+		Language:    e.Language,
+		Author:      e.Author,
+		Description: e.Description,
+		Message:     e.Message,
+		Size:        e.Size,
+		Checksum:    checksum,
+		PublishedAt: e.CreatedAt,
+		Signature:   e.Signature,
+	}
+}
+
+func stackResource(e *store.StackEntry, checksum string) Resource {
+	return Resource{
+		Kind:        KindStack,
+		Name:        e.Name,
+		Version:     e.Version,
+		Author:      e.Author,
+		Description: e.Description,
+		Message:     e.Message,
+		Size:        e.Size,
+		Checksum:    checksum,
+		PublishedAt: e.CreatedAt,
+		Signature:   e.Signature,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,247 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rishiyaduwanshi/boiler/internal/archive"
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
+)
+
+func newTestServer(t *testing.T, conf *ServerConfig) *Client {
+	t.Helper()
+
+	root := t.TempDir()
+	srv := NewServer(root, filepath.Join(root, "snippets"), filepath.Join(root, "stacks"), conf)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	client, err := NewClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestServerPublishAndFetch(t *testing.T) {
+	client := newTestServer(t, &ServerConfig{Tokens: []Token{{Name: "dev", Token: "w", Access: AccessWrite}}})
+	client.Token = "w"
+
+	snippet := PublishRequest{Kind: KindSnippet, Name: "logger", Extension: ".js", Author: "Ann", Content: []byte("// __author Ann\nv1\n")}
+	res, err := client.Publish(snippet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FullName() != "logger@1.0.0.js" {
+		t.Fatalf("first publish = %s, want logger@1.0.0.js", res.FullName())
+	}
+	snippet.Content = []byte("// __author Ann\nv2\n")
+	if res, err = client.Publish(snippet); err != nil || res.FullName() != "logger@1.0.1.js" {
+		t.Fatalf("second publish = %v, %v; want logger@1.0.1.js", res, err)
+	}
+
+	stackDir := t.TempDir()
+	os.WriteFile(filepath.Join(stackDir, "app.js"), []byte("app()\n"), 0644)
+	os.WriteFile(filepath.Join(stackDir, "boiler.stack.json"), []byte(`{"id":"express","version":"1.0.0"}`), 0644)
+	var stack bytes.Buffer
+	if err := archive.Pack(&stack, stackDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	stackReq := PublishRequest{Kind: KindStack, Name: "express", Version: "1.0.0", Content: stack.Bytes()}
+	if _, err := client.Publish(stackReq); err != nil {
+		t.Fatal(err)
+	}
+	var regErr *Error
+	if _, err := client.Publish(stackReq); !errors.As(err, &regErr) || regErr.Status != http.StatusConflict {
+		t.Fatalf("duplicate stack publish error = %v, want 409", err)
+	}
+
+	idx, err := client.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Resources) != 3 {
+		t.Fatalf("index has %d resources, want 3", len(idx.Resources))
+	}
+
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest, err := idx.Resolve("logger", "", ".js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := cache.Fetch(client, latest, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, _ := cache.Store().GetSnippet(name)
+	if data, _ := os.ReadFile(entry.Path); string(data) != "// __author Ann\nv2\n" {
+		t.Fatalf("fetched snippet = %q, want v2", data)
+	}
+
+	express, _ := idx.Resolve("express", "", "")
+	if name, err = cache.Fetch(client, express, nil); err != nil {
+		t.Fatal(err)
+	}
+	stackEntry, _ := cache.Store().GetStack(name)
+	if _, err := os.Stat(filepath.Join(stackEntry.Path, "app.js")); err != nil {
+		t.Fatalf("stack not unpacked: %v", err)
+	}
+}
+
+func TestServerAuth(t *testing.T) {
+	client := newTestServer(t, &ServerConfig{Tokens: []Token{
+		{Name: "ci", Token: "r", Access: AccessRead},
+		{Name: "dev", Token: "w", Access: AccessWrite},
+	}})
+	req := PublishRequest{Kind: KindSnippet, Name: "util", Extension: ".go", Content: []byte("// __author Ann\npackage util\n")}
+
+	tests := []struct {
+		token       string
+		indexStatus int
+		pubStatus   int
+	}{
+		{"", http.StatusUnauthorized, http.StatusUnauthorized},
+		{"wrong", http.StatusUnauthorized, http.StatusUnauthorized},
+		{"r", 0, http.StatusForbidden},
+		{"w", 0, 0},
+	}
+	for _, tt := range tests {
+		client.Token = tt.token
+		_, err := client.Index()
+		if got := status(err); got != tt.indexStatus {
+			t.Errorf("token %q: index status %d, want %d (%v)", tt.token, got, tt.indexStatus, err)
+		}
+		_, err = client.Publish(req)
+		if got := status(err); got != tt.pubStatus {
+			t.Errorf("token %q: publish status %d, want %d (%v)", tt.token, got, tt.pubStatus, err)
+		}
+	}
+}
+
+func TestServerValidatesLikeStore(t *testing.T) {
+	client := newTestServer(t, &ServerConfig{Tokens: []Token{{Name: "dev", Token: "w", Access: AccessWrite}}})
+	client.Token = "w"
+
+	for name, content := range map[string]string{
+		"no author":   "console.log(1)\n",
+		"invalid var": "// __author Ann\n// __var bl__PORT:color\n",
+	} {
+		req := PublishRequest{Kind: KindSnippet, Name: "logger", Extension: ".js", Author: "Ann", Content: []byte(content)}
+		if _, err := client.Publish(req); status(err) != http.StatusBadRequest {
+			t.Errorf("%s: publish = %v, want 400", name, err)
+		}
+	}
+
+	stackDir := t.TempDir()
+	os.WriteFile(filepath.Join(stackDir, "app.js"), []byte("app()\n"), 0644)
+	os.WriteFile(filepath.Join(stackDir, "boiler.stack.json"), []byte(`{"id":"express","version":"1.0.0"}`), 0644)
+	var stack bytes.Buffer
+	if err := archive.Pack(&stack, stackDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range []PublishRequest{
+		{Kind: KindStack, Name: "koa", Version: "1.0.0", Content: stack.Bytes()},
+		{Kind: KindStack, Name: "express", Version: "2.0.0", Content: stack.Bytes()},
+	} {
+		if _, err := client.Publish(req); status(err) != http.StatusBadRequest {
+			t.Errorf("publish %s@%s of express@1.0.0 = %v, want 400", req.Name, req.Version, err)
+		}
+	}
+
+	idx, err := client.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Resources) != 0 {
+		t.Fatalf("index holds %v after rejected uploads", idx.Resources)
+	}
+}
+
+func TestServerNormalizesVersions(t *testing.T) {
+	client := newTestServer(t, &ServerConfig{Tokens: []Token{{Name: "dev", Token: "w", Access: AccessWrite}}})
+	client.Token = "w"
+
+	req := PublishRequest{Kind: KindSnippet, Name: "logger", Version: "1", Extension: ".js", Content: []byte("// __author Ann\nv1\n")}
+	res, err := client.Publish(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FullName() != "logger@1.0.0.js" {
+		t.Fatalf("publish as 1 = %s, want logger@1.0.0.js", res.FullName())
+	}
+
+	// The same version spelled another way is a duplicate
+	req.Version = "1.0"
+	if _, err := client.Publish(req); status(err) != http.StatusConflict {
+		t.Fatalf("publish as 1.0 = %v, want 409", err)
+	}
+}
+
+func TestServerChecksSignatures(t *testing.T) {
+	client := newTestServer(t, &ServerConfig{Tokens: []Token{{Name: "dev", Token: "w", Access: AccessWrite}}})
+	client.Token = "w"
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	content := []byte("// __author Ann\nv1\n")
+	digest, err := ContentDigest(KindSnippet, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := PublishRequest{Kind: KindSnippet, Name: "logger", Version: "1.0.0", Extension: ".js", Content: content}
+	req.Signature = signing.Sign(key, signing.ResourceMessage(KindSnippet, "logger@1.0.0.js", Checksum([]byte("other"))))
+	if _, err := client.Publish(req); status(err) != http.StatusBadRequest {
+		t.Fatalf("publish with a wrong signature: %v, want 400", err)
+	}
+
+	// A signature for one version does not cover another
+	req.Signature = signing.Sign(key, signing.ResourceMessage(KindSnippet, "logger@0.9.0.js", digest))
+	if _, err := client.Publish(req); status(err) != http.StatusBadRequest {
+		t.Fatalf("publish with another version's signature: %v, want 400", err)
+	}
+
+	// The version is signed, so the registry cannot pick it
+	unversioned := req
+	unversioned.Version = ""
+	unversioned.Signature = signing.Sign(key, signing.ResourceMessage(KindSnippet, "logger@1.0.0.js", digest))
+	if _, err := client.Publish(unversioned); status(err) != http.StatusBadRequest {
+		t.Fatalf("signed publish without version: %v, want 400", err)
+	}
+
+	req.Signature = signing.Sign(key, signing.ResourceMessage(KindSnippet, "logger@1.0.0.js", digest))
+	if _, err := client.Publish(req); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := client.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := idx.Resolve("logger", "", ".js")
+	if r.Signature == nil || signing.Check(r.Signature, signing.ResourceMessage(r.Kind, r.FullName(), digest)) != nil {
+		t.Fatalf("index signature = %+v, want a valid signature", r.Signature)
+	}
+	if next, err := idx.NextVersion(KindSnippet, "logger", ".js", "minor"); err != nil || next != "1.1.0" {
+		t.Fatalf("NextVersion() = %q, %v; want 1.1.0", next, err)
+	}
+}
+
+// status returns the HTTP status of a registry error, or 0 for success
+func status(err error) int {
+	var regErr *Error
+	if errors.As(err, &regErr) {
+		return regErr.Status
+	}
+	if err != nil {
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,182 @@
+// Package signing signs and verifies bundles and published resources with
+// ed25519 keys. Public keys are written as "ed25519:<base64>", the form kept
+// in the trusted keys list of the config.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Verification policies
+const (
+	PolicyOff     = "off"
+	PolicyWarn    = "warn"
+	PolicyRequire = "require"
+)
+
+// keyPrefix marks the algorithm of a public key string
+const keyPrefix = "ed25519:"
+
+var (
+	// ErrUnsigned is returned when there is no signature to verify
+	ErrUnsigned = errors.New("not signed")
+	// ErrUntrusted is returned for a valid signature by a key not in the
+	// trusted keys list
+	ErrUntrusted = errors.New("signed by an untrusted key")
+	// ErrInvalid is returned when a signature does not match the content
+	ErrInvalid = errors.New("invalid signature")
+)
+
+// Signature is a detached signature together with the key that made it
+type Signature struct {
+	KeyID     string `json:"keyId"`
+	PublicKey string `json:"publicKey"`
+	Value     string `json:"signature"`
+}
+
+// TrustedKey is a public key whose signatures are accepted
+type TrustedKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// ResourceMessage is what is signed for a published resource: its kind,
+// full name with version (logger@1.0.0.js, express@1.0.0) and content
+// digest, the checksum the store keeps for it. Covering the version keeps
+// a registry from serving an older signed release as a newer one.
+func ResourceMessage(kind, fullName, digest string) []byte {
+	return []byte("boiler-resource-v2\n" + kind + "\n" + fullName + "\n" + digest)
+}
+
+// BundleMessage is what is signed for a bundle: its manifest, which holds
+// the SHA-256 of every file
+func BundleMessage(manifest []byte) []byte {
+	return append([]byte("boiler-bundle-v1\n"), manifest...)
+}
+
+// GenerateKey writes a new private key to path (mode 0600) and its public
+// key to path + ".pub", and returns the public key string
+func GenerateKey(path string) (string, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create key directory: %w", err)
+	}
+	block := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, block, 0600); err != nil {
+		return "", fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	encoded := EncodePublicKey(pub)
+	if err := os.WriteFile(path+".pub", []byte(encoded+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write public key: %w", err)
+	}
+	return encoded, nil
+}
+
+// LoadPrivateKey reads a private key written by GenerateKey
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM private key", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 key", path)
+	}
+	return priv, nil
+}
+
+// EncodePublicKey returns the "ed25519:<base64>" form of a public key
+func EncodePublicKey(pub ed25519.PublicKey) string {
+	return keyPrefix + base64.StdEncoding.EncodeToString(pub)
+}
+
+// ParsePublicKey parses the "ed25519:<base64>" form of a public key
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, keyPrefix) {
+		return nil, fmt.Errorf("public key must start with %q", keyPrefix)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, keyPrefix))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key")
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// KeyID returns a short fingerprint of a public key
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Sign signs message with priv
+func Sign(priv ed25519.PrivateKey, message []byte) *Signature {
+	pub := priv.Public().(ed25519.PublicKey)
+	return &Signature{
+		KeyID:     KeyID(pub),
+		PublicKey: EncodePublicKey(pub),
+		Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(priv, message)),
+	}
+}
+
+// Check reports whether sig is a valid signature of message by the key it
+// names, without looking at whether that key is trusted
+func Check(sig *Signature, message []byte) error {
+	if sig == nil {
+		return ErrUnsigned
+	}
+	pub, err := ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	value, err := base64.StdEncoding.DecodeString(sig.Value)
+	if err != nil || !ed25519.Verify(pub, message, value) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// Verify checks sig against message and the trusted keys and returns the
+// name of the trusted key that made it
+func Verify(sig *Signature, message []byte, trusted []TrustedKey) (string, error) {
+	if err := Check(sig, message); err != nil {
+		return "", err
+	}
+
+	pub, _ := ParsePublicKey(sig.PublicKey)
+	for _, t := range trusted {
+		key, err := ParsePublicKey(t.Key)
+		if err == nil && key.Equal(pub) {
+			return t.Name, nil
+		}
+	}
+	return "", fmt.Errorf("%w (key %s)", ErrUntrusted, KeyID(pub))
+}
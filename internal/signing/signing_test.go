@@ -0,0 +1,51 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "boiler.key")
+	pub, err := GenerateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := LoadPrivateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := EncodePublicKey(priv.Public().(ed25519.PublicKey)); got != pub {
+		t.Fatalf("loaded key has public key %s, want %s", got, pub)
+	}
+
+	message := ResourceMessage("snippet", "logger@1.0.0.js", "abc")
+	sig := Sign(priv, message)
+	trusted := []TrustedKey{{Name: "ann", Key: pub}}
+
+	if name, err := Verify(sig, message, trusted); err != nil || name != "ann" {
+		t.Fatalf("Verify() = %q, %v; want ann", name, err)
+	}
+	if _, err := Verify(sig, message, nil); !errors.Is(err, ErrUntrusted) {
+		t.Fatalf("Verify(untrusted) error = %v, want ErrUntrusted", err)
+	}
+	if _, err := Verify(sig, ResourceMessage("snippet", "logger@1.0.0.js", "abd"), trusted); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Verify(changed content) error = %v, want ErrInvalid", err)
+	}
+	if _, err := Verify(sig, ResourceMessage("snippet", "logger@1.1.0.js", "abc"), trusted); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Verify(other version) error = %v, want ErrInvalid", err)
+	}
+	if _, err := Verify(nil, message, trusted); !errors.Is(err, ErrUnsigned) {
+		t.Fatalf("Verify(nil) error = %v, want ErrUnsigned", err)
+	}
+
+	// A signature that names another key than the one that made it
+	_, other, _ := ed25519.GenerateKey(nil)
+	forged := *sig
+	forged.PublicKey = EncodePublicKey(other.Public().(ed25519.PublicKey))
+	if _, err := Verify(&forged, message, trusted); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Verify(forged) error = %v, want ErrInvalid", err)
+	}
+}
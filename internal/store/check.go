@@ -0,0 +1,178 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProblemKind identifies a class of store integrity problem
+type ProblemKind string
+
+const (
+	// ProblemMissingPath is a meta entry whose file or directory does not exist
+	ProblemMissingPath ProblemKind = "missing-path"
+	// ProblemOrphan is a file or directory in the store that has no meta entry
+	ProblemOrphan ProblemKind = "orphan"
+	// ProblemMissingConfig is a stored stack without a boiler.stack.json
+	ProblemMissingConfig ProblemKind = "missing-config"
+	// ProblemLegacyConfig is a stack stored before stacks kept their
+	// boiler.stack.json. It is not damaged, only missing the config.
+	ProblemLegacyConfig ProblemKind = "legacy-config"
+)
+
+// StackConfigFile is the name of the config file every stack carries
+const StackConfigFile = "boiler.stack.json"
+
+// Problem describes a single integrity problem found by Check
+type Problem struct {
+	Kind ProblemKind
+	// Type is either "snippet" or "stack"
+	Type string
+	// Name is the meta key, empty for orphans
+	Name string
+	Path string
+}
+
+func (p Problem) String() string {
+	switch p.Kind {
+	case ProblemMissingPath:
+		return fmt.Sprintf("%s '%s' points at missing path %s", p.Type, p.Name, p.Path)
+	case ProblemOrphan:
+		return fmt.Sprintf("%s %s is not in the store index", p.Type, p.Path)
+	case ProblemMissingConfig:
+		return fmt.Sprintf("stack '%s' has no %s in %s", p.Name, StackConfigFile, p.Path)
+	case ProblemLegacyConfig:
+		return fmt.Sprintf("stack '%s' was stored without a %s", p.Name, StackConfigFile)
+	}
+	return fmt.Sprintf("%s: %s", p.Kind, p.Path)
+}
+
+// Check compares the meta file with the snippet and stack directories and
+// reports entries without files, files without entries and stacks that are
+// missing their config file. Stacks whose recorded files never included a
+// config were stored before configs were kept and are reported as legacy
+// rather than missing. Results are sorted: entries first, then orphans.
+func (s *Store) Check(snippetsDir, stacksDir string) ([]Problem, error) {
+	var problems []Problem
+
+	snippetPaths := make(map[string]bool)
+	for _, name := range s.ListSnippets() {
+		entry := s.meta.Snippets[name]
+		snippetPaths[cleanPath(entry.Path)] = true
+		if info, err := os.Stat(entry.Path); err != nil || info.IsDir() {
+			problems = append(problems, Problem{Kind: ProblemMissingPath, Type: "snippet", Name: name, Path: entry.Path})
+		}
+	}
+
+	stackPaths := make(map[string]bool)
+	for _, name := range s.ListStacks() {
+		entry := s.meta.Stacks[name]
+		stackPaths[cleanPath(entry.Path)] = true
+		info, err := os.Stat(entry.Path)
+		if err != nil || !info.IsDir() {
+			problems = append(problems, Problem{Kind: ProblemMissingPath, Type: "stack", Name: name, Path: entry.Path})
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(entry.Path, StackConfigFile)); err != nil {
+			kind := ProblemMissingConfig
+			if _, ok := entry.Files[StackConfigFile]; !ok {
+				kind = ProblemLegacyConfig
+			}
+			problems = append(problems, Problem{Kind: kind, Type: "stack", Name: name, Path: entry.Path})
+		}
+	}
+
+	snippetFiles, err := ScanSnippetFiles(snippetsDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range snippetFiles {
+		if !snippetPaths[cleanPath(path)] {
+			problems = append(problems, Problem{Kind: ProblemOrphan, Type: "snippet", Path: path})
+		}
+	}
+
+	stackDirs, err := ScanStackDirs(stacksDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range stackDirs {
+		if !stackPaths[cleanPath(path)] {
+			problems = append(problems, Problem{Kind: ProblemOrphan, Type: "stack", Path: path})
+		}
+	}
+
+	return problems, nil
+}
+
+// ScanSnippetFiles returns every snippet file below dir. Hidden files are
+// skipped, they are never created by boiler.
+func ScanSnippetFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") && p != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan snippets: %w", err)
+	}
+	return files, nil
+}
+
+// ScanStackDirs returns every stack directory directly below dir
+func ScanStackDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to scan stacks: %w", err)
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			dirs = append(dirs, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// Replace swaps the whole index for the given entries in one meta update
+func (s *Store) Replace(snippets []*SnippetEntry, stacks []*StackEntry) error {
+	return s.Update(func() error {
+		s.meta.Snippets = make(map[string]*SnippetEntry, len(snippets))
+		for _, entry := range snippets {
+			s.meta.Snippets[entry.FullName()] = entry
+		}
+		s.meta.Stacks = make(map[string]*StackEntry, len(stacks))
+		for _, entry := range stacks {
+			s.meta.Stacks[entry.FullName()] = entry
+		}
+		s.pruneTags()
+		return nil
+	})
+}
+
+func cleanPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestCheckFindsProblems(t *testing.T) {
+	root := t.TempDir()
+	snippetsDir := filepath.Join(root, "snippets")
+	stacksDir := filepath.Join(root, "stacks")
+	for _, path := range []string{
+		filepath.Join(snippetsDir, "js", "kept@1.js"),
+		filepath.Join(snippetsDir, "js", "orphan@1.js"),
+		filepath.Join(snippetsDir, "js", ".hidden.js"),
+		filepath.Join(stacksDir, "api@1", "main.go"),
+		filepath.Join(stacksDir, "old@1", "main.go"),
+		filepath.Join(stacksDir, "web@1", StackConfigFile),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := NewStore(root)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range []*SnippetEntry{
+		{Name: "kept", Version: "1", Extension: ".js", Path: filepath.Join(snippetsDir, "js", "kept@1.js")},
+		{Name: "gone", Version: "1", Extension: ".js", Path: filepath.Join(snippetsDir, "js", "gone@1.js")},
+	} {
+		if err := st.AddSnippet(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// api@1 was stored with a config that has since gone, old@1 was stored
+	// before stacks kept their config
+	for _, entry := range []*StackEntry{
+		{Name: "api", Version: "1", Path: filepath.Join(stacksDir, "api@1"), Files: map[string]string{"main.go": "a", StackConfigFile: "b"}},
+		{Name: "old", Version: "1", Path: filepath.Join(stacksDir, "old@1"), Files: map[string]string{"main.go": "a"}},
+	} {
+		if err := st.AddStack(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	problems, err := st.Check(snippetsDir, stacksDir)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, string(p.Kind)+" "+p.Type+" "+p.Name+" "+filepath.Base(p.Path))
+	}
+	sort.Strings(got)
+	want := []string{
+		"legacy-config stack old@1 old@1",
+		"missing-config stack api@1 api@1",
+		"missing-path snippet gone@1.js gone@1.js",
+		"orphan snippet  orphan@1.js",
+		"orphan stack  web@1",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Check() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Check() = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FileChecksum returns the hex encoded SHA-256 digest of a file
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DirChecksum returns a SHA-256 digest over every file in a directory.
+// Files are visited in sorted order and both relative path and content
+// contribute to the digest, so renames are detected as well as edits.
+func DirChecksum(root string) (string, error) {
+	files, err := FileDigests(root)
+	if err != nil {
+		return "", err
+	}
+	return DigestRoot(files), nil
+}
+
+// FileDigests returns the SHA-256 of every file in a directory, keyed by
+// its slash-separated path relative to root
+func FileDigests(root string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		sum, err := FileChecksum(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+	return files, nil
+}
+
+// DigestRoot combines per-file digests into a single digest, the one
+// DirChecksum returns. It hashes one "sum  path" line per file in path order.
+func DigestRoot(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, p := range paths {
+		fmt.Fprintf(h, "%s  %s\n", files[p], p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DirSize returns the total size in bytes of all files in a directory
+func DirSize(root string) (int64, error) {
+	var size int64
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
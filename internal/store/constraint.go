@@ -0,0 +1,229 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TagLatest always resolves to the highest stored version
+const TagLatest = "latest"
+
+// specPrefixRe matches a version, optionally preceded by a constraint
+// operator, at the start of the text following '@' in a resource name
+var specPrefixRe = regexp.MustCompile(`^(?:[~^=]|[<>]=?)?\d+(?:\.\d+){0,2}`)
+
+// tagRe is the format of user-defined tags such as stable or beta
+var tagRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Constraint selects versions. Supported forms:
+//
+//	1.2.3     exactly this version (1 and 1.0.0 are equal)
+//	^1.2.3    >=1.2.3 and <2.0.0 (<0.3.0 for 0.2.x)
+//	~1.2.3    >=1.2.3 and <1.3.0 (~1 is >=1.0.0 and <2.0.0)
+//	>=1.2 >1.2 <=1.2 <1.2 =1.2
+type Constraint struct {
+	op    string
+	v     Version
+	parts int
+}
+
+// ParseConstraint parses a version constraint. Tags are not constraints,
+// use ValidateTag to check those.
+func ParseConstraint(spec string) (Constraint, error) {
+	var c Constraint
+	spec = strings.TrimSpace(spec)
+
+	for _, op := range []string{">=", "<=", ">", "<", "^", "~", "="} {
+		if strings.HasPrefix(spec, op) {
+			c.op = op
+			spec = strings.TrimSpace(strings.TrimPrefix(spec, op))
+			break
+		}
+	}
+
+	v, err := ParseVersion(spec)
+	if err != nil {
+		return c, err
+	}
+	c.v = v
+	c.parts = strings.Count(spec, ".") + 1
+	return c, nil
+}
+
+// Match reports whether v satisfies the constraint
+func (c Constraint) Match(v Version) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	case "^":
+		if cmp < 0 {
+			return false
+		}
+		if c.v.Major > 0 || c.parts == 1 {
+			return v.Major == c.v.Major
+		}
+		return v.Major == 0 && v.Minor == c.v.Minor
+	case "~":
+		if cmp < 0 {
+			return false
+		}
+		if c.parts == 1 {
+			return v.Major == c.v.Major
+		}
+		return v.Major == c.v.Major && v.Minor == c.v.Minor
+	}
+	return cmp == 0
+}
+
+// ValidateTag checks that a user-defined tag can't be mistaken for a
+// version or constraint
+func ValidateTag(tag string) error {
+	if tag == TagLatest {
+		return fmt.Errorf("'%s' is reserved and always points at the highest version", TagLatest)
+	}
+	if !tagRe.MatchString(tag) {
+		return fmt.Errorf("invalid tag '%s': use letters, digits, '-' and '_', starting with a letter", tag)
+	}
+	return nil
+}
+
+// ResourceKey identifies all versions of one resource, e.g. logger.js or express
+func ResourceKey(name, extension string) string {
+	return name + extension
+}
+
+// ResolveSnippet picks the snippet version matching spec, which may be an
+// exact version, a constraint, a tag or empty for the latest version
+func (s *Store) ResolveSnippet(name, extension, spec string) (*SnippetEntry, error) {
+	key := ResourceKey(name, extension)
+	version, err := s.resolve(key, s.GetAllVersions(name, extension), spec)
+	if err != nil {
+		return nil, fmt.Errorf("snippet '%s': %w", key, err)
+	}
+	entry, _ := s.GetSnippet(FormatResourceName(name, version, extension))
+	return entry, nil
+}
+
+// ResolveStack picks the stack version matching spec, see ResolveSnippet
+func (s *Store) ResolveStack(name, spec string) (*StackEntry, error) {
+	version, err := s.resolve(name, s.GetAllStackVersions(name), spec)
+	if err != nil {
+		return nil, fmt.Errorf("stack '%s': %w", name, err)
+	}
+	entry, _ := s.GetStack(FormatResourceName(name, version, ""))
+	return entry, nil
+}
+
+func (s *Store) resolve(key string, versions []string, spec string) (string, error) {
+	return ResolveVersion(versions, s.meta.Tags[key], spec)
+}
+
+// ResolveVersion picks the version matching spec from versions sorted
+// ascending. tags maps tag names to versions and may be nil.
+func ResolveVersion(versions []string, tags map[string]string, spec string) (string, error) {
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no versions stored")
+	}
+
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == TagLatest {
+		return versions[len(versions)-1], nil
+	}
+
+	if version, ok := tags[spec]; ok {
+		for _, v := range versions {
+			if v == version {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("tag '%s' points at version %s, which is no longer stored", spec, version)
+	}
+
+	c, err := ParseConstraint(spec)
+	if err != nil {
+		if ValidateTag(spec) == nil {
+			return "", fmt.Errorf("unknown tag '%s'", spec)
+		}
+		return "", fmt.Errorf("invalid version or constraint '%s'", spec)
+	}
+
+	// Highest matching version wins; versions are sorted ascending
+	for i := len(versions) - 1; i >= 0; i-- {
+		v, err := ParseVersion(versions[i])
+		if err == nil && c.Match(v) {
+			return versions[i], nil
+		}
+	}
+	return "", fmt.Errorf("no version matches '%s'", spec)
+}
+
+// Tags returns a copy of the tags of a resource, tag -> version
+func (s *Store) Tags(key string) map[string]string {
+	tags := make(map[string]string, len(s.meta.Tags[key]))
+	for tag, version := range s.meta.Tags[key] {
+		tags[tag] = version
+	}
+	return tags
+}
+
+// SetTag points tag at a version of the resource identified by key,
+// moving it if it already exists
+func (s *Store) SetTag(key, tag, version string) error {
+	if err := ValidateTag(tag); err != nil {
+		return err
+	}
+	return s.Update(func() error {
+		if s.meta.Tags == nil {
+			s.meta.Tags = make(map[string]map[string]string)
+		}
+		if s.meta.Tags[key] == nil {
+			s.meta.Tags[key] = make(map[string]string)
+		}
+		s.meta.Tags[key][tag] = version
+		return nil
+	})
+}
+
+// RemoveTag deletes a tag from a resource
+func (s *Store) RemoveTag(key, tag string) error {
+	return s.Update(func() error {
+		if _, ok := s.meta.Tags[key][tag]; !ok {
+			return fmt.Errorf("tag '%s' not found on '%s'", tag, key)
+		}
+		delete(s.meta.Tags[key], tag)
+		if len(s.meta.Tags[key]) == 0 {
+			delete(s.meta.Tags, key)
+		}
+		return nil
+	})
+}
+
+// pruneTags drops tags whose version is no longer stored
+func (s *Store) pruneTags() {
+	exists := make(map[string]bool)
+	for _, e := range s.meta.Snippets {
+		exists[ResourceKey(e.Name, e.Extension)+"@"+e.Version] = true
+	}
+	for _, e := range s.meta.Stacks {
+		exists[ResourceKey(e.Name, "")+"@"+e.Version] = true
+	}
+
+	for key, tags := range s.meta.Tags {
+		for tag, version := range tags {
+			if !exists[key+"@"+version] {
+				delete(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			delete(s.meta.Tags, key)
+		}
+	}
+}
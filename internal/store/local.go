@@ -0,0 +1,82 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LocalDir is the directory name of a project-local store. It has the same
+// layout as the global store and is meant to be checked into the project.
+const LocalDir = ".boiler"
+
+// storeGitignore keeps transaction and lock files of a store out of git
+const storeGitignore = `# Transaction and lock files of the Boiler store
+.tx/
+*.lock
+`
+
+// FindLocal walks up from dir looking for a project-local store, i.e. a
+// .boiler directory holding a store index. Directories in exclude, such as
+// the global Boiler root, are skipped.
+func FindLocal(dir string, exclude ...string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	skip := make(map[string]bool, len(exclude))
+	for _, e := range exclude {
+		if abs, err := filepath.Abs(e); err == nil {
+			skip[abs] = true
+		}
+	}
+
+	for {
+		candidate := filepath.Join(dir, LocalDir)
+		if !skip[candidate] {
+			if info, err := os.Stat(filepath.Join(candidate, MetaFile)); err == nil && !info.IsDir() {
+				return candidate, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// InitLocal creates a project-local store in dir and returns its path
+func InitLocal(dir string) (string, error) {
+	root, err := filepath.Abs(filepath.Join(dir, LocalDir))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", fmt.Errorf("failed to create local store: %w", err)
+	}
+
+	if err := EnsureGitignore(root); err != nil {
+		return "", err
+	}
+
+	if err := NewStore(root).Load(); err != nil {
+		return "", err
+	}
+	return root, nil
+}
+
+// EnsureGitignore writes a .gitignore for transaction and lock files into
+// the store directory root unless one exists
+func EnsureGitignore(root string) error {
+	gitignore := filepath.Join(root, ".gitignore")
+	if _, err := os.Stat(gitignore); !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.WriteFile(gitignore, []byte(storeGitignore), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", gitignore, err)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package store
+
+import "os"
+
+// Platforms without file locking fall back to atomic writes only
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MergeMeta merges two versions of a meta file that grew apart from base,
+// e.g. when syncing a store, entry by entry instead of line by line. An
+// entry changed on one side only takes that change, including removal.
+// When both sides changed the same entry differently, the one updated last
+// wins and its name is returned in conflicts. Tags are merged per tag and
+// keep ours on conflict. Missing or empty inputs count as an empty meta.
+func MergeMeta(base, ours, theirs []byte) (merged []byte, conflicts []string, err error) {
+	var b, o, t rawMeta
+	for _, m := range []struct {
+		data []byte
+		into *rawMeta
+		side string
+	}{{base, &b, "base"}, {ours, &o, "ours"}, {theirs, &t, "theirs"}} {
+		if len(bytes.TrimSpace(m.data)) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(m.data, m.into); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s meta file: %w", m.side, err)
+		}
+	}
+
+	// Same layout as Meta, so a merged file reads like one the store wrote
+	out := struct {
+		Stacks   map[string]json.RawMessage   `json:"stacks"`
+		Snippets map[string]json.RawMessage   `json:"snippets"`
+		Tags     map[string]map[string]string `json:"tags,omitempty"`
+	}{
+		Snippets: mergeEntries(b.Snippets, o.Snippets, t.Snippets, &conflicts),
+		Stacks:   mergeEntries(b.Stacks, o.Stacks, t.Stacks, &conflicts),
+		Tags:     mergeTags(b.Tags, o.Tags, t.Tags),
+	}
+	sort.Strings(conflicts)
+
+	merged, err = json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal meta: %w", err)
+	}
+	return merged, conflicts, nil
+}
+
+// mergeEntries does a three-way merge of one entry map
+func mergeEntries(base, ours, theirs map[string]json.RawMessage, conflicts *[]string) map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage)
+	for name := range unionKeys(base, ours, theirs) {
+		b, o, t := base[name], ours[name], theirs[name]
+
+		var pick json.RawMessage
+		switch {
+		case sameJSON(o, t), sameJSON(t, b):
+			pick = o
+		case sameJSON(o, b):
+			pick = t
+		default:
+			*conflicts = append(*conflicts, name)
+			pick = o
+			if updatedAt(t).After(updatedAt(o)) {
+				pick = t
+			}
+		}
+		if pick != nil {
+			out[name] = pick
+		}
+	}
+	return out
+}
+
+// mergeTags does a three-way merge of the tag map, tag by tag
+func mergeTags(base, ours, theirs map[string]map[string]string) map[string]map[string]string {
+	out := make(map[string]map[string]string)
+	for key := range unionKeys(base, ours, theirs) {
+		for tag := range unionKeys(base[key], ours[key], theirs[key]) {
+			b, bok := base[key][tag]
+			o, ook := ours[key][tag]
+			t, tok := theirs[key][tag]
+
+			v, ok := o, ook
+			if o == b && ook == bok {
+				v, ok = t, tok
+			}
+			if !ok {
+				continue
+			}
+			if out[key] == nil {
+				out[key] = make(map[string]string)
+			}
+			out[key][tag] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func unionKeys[V any](maps ...map[string]V) map[string]bool {
+	keys := make(map[string]bool)
+	for _, m := range maps {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	return keys
+}
+
+// sameJSON reports whether two entries are equal, ignoring formatting. A
+// nil entry, i.e. one that does not exist, only equals another nil entry.
+func sameJSON(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// updatedAt returns the updatedAt time of an entry, zero for removed or
+// legacy entries
+func updatedAt(entry json.RawMessage) time.Time {
+	var e struct {
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+	if entry != nil {
+		json.Unmarshal(entry, &e)
+	}
+	return e.UpdatedAt
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// Paths in boiler.meta.json are stored relative to the store root with
+// forward slashes, so a store can be copied, synced or moved. In memory,
+// entries always carry absolute paths.
+
+// windowsAbsRe matches drive-letter paths, which are absolute even when the
+// meta file is read on another OS
+var windowsAbsRe = regexp.MustCompile(`^[A-Za-z]:[/\\]`)
+
+func isAbsPath(path string) bool {
+	return filepath.IsAbs(path) || strings.HasPrefix(path, "/") || windowsAbsRe.MatchString(path)
+}
+
+// resolvePath turns a path from the meta file into an absolute path.
+// Absolute paths that no longer exist, e.g. written on another machine
+// before paths were made relative, are rebased onto this store when a
+// trailing part of them exists below the store root.
+func (s *Store) resolvePath(path string) string {
+	if path == "" {
+		return path
+	}
+	if !isAbsPath(path) {
+		return filepath.Join(s.root, filepath.FromSlash(path))
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	parts := strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' })
+	for i := 1; i < len(parts); i++ {
+		candidate := filepath.Join(append([]string{s.root}, parts[i:]...)...)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return path
+}
+
+// relativePath turns an absolute path into the form written to the meta
+// file. Paths outside the store root stay absolute.
+func (s *Store) relativePath(path string) string {
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// portableMeta returns a copy of the meta with store-relative paths
+func (s *Store) portableMeta() *Meta {
+	out := &Meta{
+		Stacks:   make(map[string]*StackEntry, len(s.meta.Stacks)),
+		Snippets: make(map[string]*SnippetEntry, len(s.meta.Snippets)),
+		Tags:     s.meta.Tags,
+	}
+	for name, entry := range s.meta.Snippets {
+		e := *entry
+		e.Path = s.relativePath(e.Path)
+		out.Snippets[name] = &e
+	}
+	for name, entry := range s.meta.Stacks {
+		e := *entry
+		e.Path = s.relativePath(e.Path)
+		out.Stacks[name] = &e
+	}
+	return out
+}
+
+// Relocate moves the whole store directory to newRoot while holding the
+// store lock. newRoot must not exist yet, or be an empty directory. Entry
+// paths are store-relative, so the meta file moves along unchanged.
+func (s *Store) Relocate(newRoot string) error {
+	newRoot, err := filepath.Abs(newRoot)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	oldRoot, err := filepath.Abs(s.root)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	if newRoot == oldRoot {
+		return fmt.Errorf("store is already at %s", newRoot)
+	}
+	if rel, err := filepath.Rel(oldRoot, newRoot); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("cannot move the store into itself")
+	}
+
+	if entries, err := os.ReadDir(newRoot); err == nil {
+		if len(entries) > 0 {
+			return fmt.Errorf("destination '%s' is not empty", newRoot)
+		}
+		if err := os.Remove(newRoot); err != nil {
+			return fmt.Errorf("failed to prepare destination: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read destination: %w", err)
+	}
+
+	lock, err := acquireLock(s.lockPath)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	if _, _, err := s.read(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newRoot), 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	if err := moveDir(oldRoot, newRoot); err != nil {
+		return fmt.Errorf("failed to move store: %w", err)
+	}
+
+	// Re-point in-memory entries at the new location
+	for _, entry := range s.meta.Snippets {
+		entry.Path = rebasePath(entry.Path, oldRoot, newRoot)
+	}
+	for _, entry := range s.meta.Stacks {
+		entry.Path = rebasePath(entry.Path, oldRoot, newRoot)
+	}
+
+	s.root = newRoot
+	s.metaPath = filepath.Join(newRoot, filepath.Base(s.metaPath))
+	s.lockPath = s.metaPath + ".lock"
+	return s.write()
+}
+
+// rebasePath moves path from below oldRoot to below newRoot. Paths outside
+// oldRoot are returned unchanged.
+func rebasePath(path, oldRoot, newRoot string) string {
+	rel, err := filepath.Rel(oldRoot, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.Join(newRoot, rel)
+}
+
+// moveDir renames src to dst, falling back to copy and delete when the two
+// are on different file systems
+func moveDir(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree recursively copies a directory, keeping file modes
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
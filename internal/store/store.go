@@ -1,217 +1,514 @@
 package store
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/rishiyaduwanshi/boiler/internal/signing"
 )
 
 type Meta struct {
-	Stacks   map[string]string `json:"stacks"`
-	Snippets map[string]string `json:"snippets"`
+	Stacks   map[string]*StackEntry   `json:"stacks"`
+	Snippets map[string]*SnippetEntry `json:"snippets"`
+	// Tags maps a resource key (logger.js, express) to tag -> version
+	Tags map[string]map[string]string `json:"tags,omitempty"`
 }
 
+// SnippetEntry describes a single stored version of a snippet
 type SnippetEntry struct {
-	Name        string
-	Version     string
-	Extension   string
-	Path        string
-	Author      string
-	Description string
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Extension   string    `json:"extension"`
+	Language    string    `json:"language,omitempty"`
+	Path        string    `json:"path"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	// Signature is set for versions published with a signing key
+	Signature *signing.Signature `json:"signature,omitempty"`
 }
 
+// StackEntry describes a single stored version of a stack
 type StackEntry struct {
-	Name        string
-	Version     string
-	Path        string
-	Author      string
-	Description string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Path        string    `json:"path"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	// Signature is set for versions published with a signing key
+	Signature *signing.Signature `json:"signature,omitempty"`
+	// Files maps each file of the stack to its SHA-256; Checksum is the
+	// digest over them. Entries written by older versions have none.
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// FullName returns the versioned name used as the meta key, e.g. logger@1.js
+func (e *SnippetEntry) FullName() string {
+	return FormatResourceName(e.Name, e.Version, e.Extension)
+}
+
+// FullName returns the versioned name used as the meta key, e.g. express@1
+func (e *StackEntry) FullName() string {
+	return FormatResourceName(e.Name, e.Version, "")
 }
 
 type Store struct {
+	root     string
 	metaPath string
+	lockPath string
 	meta     *Meta
 }
 
+// MetaFile is the name of the store index inside a store directory
+const MetaFile = "boiler.meta.json"
+
 func NewStore(storePath string) *Store {
+	metaPath := filepath.Join(storePath, MetaFile)
 	return &Store{
-		metaPath: filepath.Join(storePath, "boiler.meta.json"),
+		root:     storePath,
+		metaPath: metaPath,
+		lockPath: metaPath + ".lock",
 		meta: &Meta{
-			Stacks:   make(map[string]string),
-			Snippets: make(map[string]string),
+			Stacks:   make(map[string]*StackEntry),
+			Snippets: make(map[string]*SnippetEntry),
 		},
 	}
 }
 
+// rawMeta is used to detect the legacy name -> path format while loading
+type rawMeta struct {
+	Stacks   map[string]json.RawMessage   `json:"stacks"`
+	Snippets map[string]json.RawMessage   `json:"snippets"`
+	Tags     map[string]map[string]string `json:"tags"`
+}
+
+// Root returns the store directory
+func (s *Store) Root() string {
+	return s.root
+}
+
+// Load reads the meta file. A missing file is created and a file in the
+// legacy name -> path format is migrated and written back.
 func (s *Store) Load() error {
+	exists, migrated, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	// Persist under the lock so the conversion only happens once
+	if !exists || migrated {
+		return s.Update(func() error { return nil })
+	}
+
+	return nil
+}
+
+// Save writes the in-memory meta to disk while holding the store lock.
+// Prefer Update for load-modify-save cycles so concurrent changes are kept.
+func (s *Store) Save() error {
+	lock, err := acquireLock(s.lockPath)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	return s.write()
+}
+
+// Update runs fn while holding the store lock. The meta file is re-read
+// before fn so that entries written by other boiler processes are not lost,
+// and written back atomically only if fn succeeds.
+func (s *Store) Update(fn func() error) error {
+	lock, err := acquireLock(s.lockPath)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	if _, _, err := s.read(); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return s.write()
+}
+
+// WithLock runs fn while holding the store lock, for work that rewrites
+// the meta file outside of the Store, such as a git merge. The meta file is
+// re-read afterwards.
+func (s *Store) WithLock(fn func() error) error {
+	lock, err := acquireLock(s.lockPath)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	if err := fn(); err != nil {
+		return err
+	}
+	_, _, err = s.read()
+	return err
+}
+
+// read replaces the in-memory meta with the contents of the meta file
+func (s *Store) read() (exists, migrated bool, err error) {
 	data, err := os.ReadFile(s.metaPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return s.Save()
+			return false, false, nil
 		}
-		return fmt.Errorf("failed to read meta file: %w", err)
+		return false, false, fmt.Errorf("failed to read meta file: %w", err)
 	}
 
-	if err := json.Unmarshal(data, s.meta); err != nil {
-		return fmt.Errorf("failed to parse meta file: %w", err)
+	var raw rawMeta
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return true, false, fmt.Errorf("failed to parse meta file: %w", err)
 	}
 
-	if s.meta.Stacks == nil {
-		s.meta.Stacks = make(map[string]string)
+	snippets := make(map[string]*SnippetEntry, len(raw.Snippets))
+	for name, value := range raw.Snippets {
+		if isLegacyValue(value) {
+			var path string
+			if err := json.Unmarshal(value, &path); err != nil {
+				return true, false, fmt.Errorf("failed to parse snippet '%s': %w", name, err)
+			}
+			snippets[name] = legacySnippetEntry(name, s.resolvePath(path))
+			migrated = true
+			continue
+		}
+
+		var entry SnippetEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return true, false, fmt.Errorf("failed to parse snippet '%s': %w", name, err)
+		}
+		entry.Path = s.resolvePath(entry.Path)
+		snippets[name] = &entry
 	}
-	if s.meta.Snippets == nil {
-		s.meta.Snippets = make(map[string]string)
+
+	stacks := make(map[string]*StackEntry, len(raw.Stacks))
+	for name, value := range raw.Stacks {
+		if isLegacyValue(value) {
+			var path string
+			if err := json.Unmarshal(value, &path); err != nil {
+				return true, false, fmt.Errorf("failed to parse stack '%s': %w", name, err)
+			}
+			stacks[name] = legacyStackEntry(name, s.resolvePath(path))
+			migrated = true
+			continue
+		}
+
+		var entry StackEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return true, false, fmt.Errorf("failed to parse stack '%s': %w", name, err)
+		}
+		entry.Path = s.resolvePath(entry.Path)
+		stacks[name] = &entry
 	}
 
-	return nil
+	s.meta.Snippets = snippets
+	s.meta.Stacks = stacks
+	s.meta.Tags = raw.Tags
+	return true, migrated, nil
 }
 
-func (s *Store) Save() error {
+// write atomically replaces the meta file; the caller must hold the lock
+func (s *Store) write() error {
 	if err := os.MkdirAll(filepath.Dir(s.metaPath), 0755); err != nil {
 		return fmt.Errorf("failed to create meta directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(s.meta, "", "    ")
+	data, err := json.MarshalIndent(s.portableMeta(), "", "    ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal meta: %w", err)
 	}
 
-	if err := os.WriteFile(s.metaPath, data, 0644); err != nil {
+	if err := WriteFileAtomic(s.metaPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write meta file: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Store) AddSnippet(name, path string) error {
-	s.meta.Snippets[name] = path
-	return s.Save()
+// AddSnippet records a snippet version, keeping the creation time of an
+// existing entry with the same name when it is overwritten
+func (s *Store) AddSnippet(entry *SnippetEntry) error {
+	return s.Update(func() error {
+		s.putSnippet(entry)
+		return nil
+	})
+}
+
+// AddStack records a stack version, keeping the creation time of an
+// existing entry with the same name when it is overwritten
+func (s *Store) AddStack(entry *StackEntry) error {
+	return s.Update(func() error {
+		s.putStack(entry)
+		return nil
+	})
+}
+
+func (s *Store) putSnippet(entry *SnippetEntry) {
+	now := time.Now()
+	name := entry.FullName()
+	if old, ok := s.meta.Snippets[name]; ok {
+		if !old.CreatedAt.IsZero() {
+			entry.CreatedAt = old.CreatedAt
+		}
+		// Overwriting a version without a new note keeps the old one
+		if entry.Message == "" {
+			entry.Message = old.Message
+		}
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.UpdatedAt = now
+
+	s.meta.Snippets[name] = entry
 }
 
-func (s *Store) AddStack(name, path string) error {
-	s.meta.Stacks[name] = path
-	return s.Save()
+func (s *Store) putStack(entry *StackEntry) {
+	now := time.Now()
+	name := entry.FullName()
+	if old, ok := s.meta.Stacks[name]; ok {
+		if !old.CreatedAt.IsZero() {
+			entry.CreatedAt = old.CreatedAt
+		}
+		// Overwriting a version without a new note keeps the old one
+		if entry.Message == "" {
+			entry.Message = old.Message
+		}
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.UpdatedAt = now
+
+	s.meta.Stacks[name] = entry
+}
+
+// GetSnippet returns a snippet by full name. Versions are compared by value,
+// so logger@1.0.0.js also finds a version an older store kept as logger@1.js.
+func (s *Store) GetSnippet(name string) (*SnippetEntry, bool) {
+	key, ok := s.snippetKey(name)
+	if !ok {
+		return nil, false
+	}
+	return s.meta.Snippets[key], true
+}
+
+// GetStack returns a stack by full name, comparing versions like GetSnippet
+func (s *Store) GetStack(name string) (*StackEntry, bool) {
+	key, ok := s.stackKey(name)
+	if !ok {
+		return nil, false
+	}
+	return s.meta.Stacks[key], true
 }
 
-func (s *Store) GetSnippet(name string) (string, bool) {
-	path, ok := s.meta.Snippets[name]
-	return path, ok
+// snippetKey returns the meta key of the snippet a full name refers to
+func (s *Store) snippetKey(fullName string) (string, bool) {
+	if _, ok := s.meta.Snippets[fullName]; ok {
+		return fullName, true
+	}
+	name, version, ext := ParseResourceName(fullName)
+	for key, e := range s.meta.Snippets {
+		if e.Name == name && e.Extension == ext && SameVersion(e.Version, version) {
+			return key, true
+		}
+	}
+	return "", false
 }
 
-func (s *Store) GetStack(name string) (string, bool) {
-	path, ok := s.meta.Stacks[name]
-	return path, ok
+// stackKey returns the meta key of the stack a full name refers to
+func (s *Store) stackKey(fullName string) (string, bool) {
+	if _, ok := s.meta.Stacks[fullName]; ok {
+		return fullName, true
+	}
+	name, version, _ := ParseResourceName(fullName)
+	for key, e := range s.meta.Stacks {
+		if e.Name == name && SameVersion(e.Version, version) {
+			return key, true
+		}
+	}
+	return "", false
 }
 
 func (s *Store) RemoveSnippet(name string) error {
-	delete(s.meta.Snippets, name)
-	return s.Save()
+	return s.RemoveEntries([]string{name}, nil)
 }
 
 func (s *Store) RemoveStack(name string) error {
-	delete(s.meta.Stacks, name)
-	return s.Save()
+	return s.RemoveEntries(nil, []string{name})
+}
+
+// RemoveEntries drops several snippets and stacks in a single meta update
+func (s *Store) RemoveEntries(snippets, stacks []string) error {
+	return s.Update(func() error {
+		for _, name := range snippets {
+			if key, ok := s.snippetKey(name); ok {
+				delete(s.meta.Snippets, key)
+			}
+		}
+		for _, name := range stacks {
+			if key, ok := s.stackKey(name); ok {
+				delete(s.meta.Stacks, key)
+			}
+		}
+		s.pruneTags()
+		return nil
+	})
 }
 
 func (s *Store) SnippetExists(name string) bool {
-	_, ok := s.meta.Snippets[name]
+	_, ok := s.snippetKey(name)
 	return ok
 }
 
 func (s *Store) StackExists(name string) bool {
-	_, ok := s.meta.Stacks[name]
+	_, ok := s.stackKey(name)
 	return ok
 }
 
+// ListSnippets returns the full names of all snippets, sorted by name and
+// then by version
 func (s *Store) ListSnippets() []string {
 	snippets := make([]string, 0, len(s.meta.Snippets))
 	for name := range s.meta.Snippets {
 		snippets = append(snippets, name)
 	}
+	sortResourceNames(snippets)
 	return snippets
 }
 
+// ListStacks returns the full names of all stacks, sorted by name and then
+// by version
 func (s *Store) ListStacks() []string {
 	stacks := make([]string, 0, len(s.meta.Stacks))
 	for name := range s.meta.Stacks {
 		stacks = append(stacks, name)
 	}
+	sortResourceNames(stacks)
 	return stacks
 }
 
-// GetNextVersion returns the next version number for a snippet
-// It finds all existing versions of the snippet and returns max + 1
-func (s *Store) GetNextVersion(baseName, extension string) int {
-	maxVersion := 0
-	
-	for snippetName := range s.meta.Snippets {
-		name, versionStr, ext := ParseResourceName(snippetName)
-		
-		// Match by base name and extension
-		if name == baseName && ext == extension {
-			if versionStr != "" {
-				// Parse version number
-				var version int
-				if _, err := fmt.Sscanf(versionStr, "%d", &version); err == nil {
-					if version > maxVersion {
-						maxVersion = version
-					}
-				}
-			}
-		}
+// SnippetEntries returns all snippet entries in ListSnippets order
+func (s *Store) SnippetEntries() []*SnippetEntry {
+	entries := make([]*SnippetEntry, 0, len(s.meta.Snippets))
+	for _, name := range s.ListSnippets() {
+		entries = append(entries, s.meta.Snippets[name])
+	}
+	return entries
+}
+
+// StackEntries returns all stack entries in ListStacks order
+func (s *Store) StackEntries() []*StackEntry {
+	entries := make([]*StackEntry, 0, len(s.meta.Stacks))
+	for _, name := range s.ListStacks() {
+		entries = append(entries, s.meta.Stacks[name])
 	}
-	
-	return maxVersion + 1
+	return entries
 }
 
-// GetAllVersions returns all existing version numbers for a snippet, sorted in ascending order.
-// This is used to check if a snippet already exists and provide options to overwrite or create new version.
-// Example: For snippets "logger@1.js", "logger@3.js", "logger@5.js", returns []int{1, 3, 5}
+// GetNextVersion returns the version a new snippet version gets when the
+// latest existing version is bumped by kind (major, minor or patch).
+// The first version of a snippet is 1.0.0.
+func (s *Store) GetNextVersion(baseName, extension, kind string) (string, error) {
+	if _, err := (Version{}).Bump(kind); err != nil {
+		return "", err
+	}
+
+	versions := s.GetAllVersions(baseName, extension)
+	if len(versions) == 0 {
+		return Version{Major: 1}.String(), nil
+	}
+
+	latest, err := ParseVersion(versions[len(versions)-1])
+	if err != nil {
+		return "", err
+	}
+	next, err := latest.Bump(kind)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
+
+// GetAllVersions returns all existing versions of a snippet in ascending
+// semantic order, as they appear in the snippet names.
+// Example: For snippets "logger@1.js", "logger@1.10.0.js", "logger@1.9.2.js",
+// returns []string{"1", "1.9.2", "1.10.0"}
 // Returns empty slice if no versions exist.
-func (s *Store) GetAllVersions(baseName, extension string) []int {
-	versions := []int{}
-	
+func (s *Store) GetAllVersions(baseName, extension string) []string {
+	versions := []string{}
+
 	for snippetName := range s.meta.Snippets {
-		name, versionStr, ext := ParseResourceName(snippetName)
-		
+		name, version, ext := ParseResourceName(snippetName)
+
 		// Match by base name and extension
-		if name == baseName && ext == extension {
-			if versionStr != "" {
-				// Parse version number
-				var version int
-				if _, err := fmt.Sscanf(versionStr, "%d", &version); err == nil {
-					versions = append(versions, version)
-				}
+		if name == baseName && ext == extension && version != "" {
+			if _, err := ParseVersion(version); err == nil {
+				versions = append(versions, version)
 			}
 		}
 	}
-	
-	// Sort versions
-	for i := 0; i < len(versions)-1; i++ {
-		for j := i + 1; j < len(versions); j++ {
-			if versions[i] > versions[j] {
-				versions[i], versions[j] = versions[j], versions[i]
+
+	SortVersions(versions)
+	return versions
+}
+
+// GetAllStackVersions returns all existing versions of a stack in ascending
+// semantic order
+func (s *Store) GetAllStackVersions(baseName string) []string {
+	versions := []string{}
+
+	for stackName := range s.meta.Stacks {
+		name, version, _ := ParseResourceName(stackName)
+		if name == baseName && version != "" {
+			if _, err := ParseVersion(version); err == nil {
+				versions = append(versions, version)
 			}
 		}
 	}
-	
+
+	SortVersions(versions)
 	return versions
 }
 
+// ParseResourceName splits a resource name into name, version and extension.
+// A version of up to three numeric parts, optionally with a constraint
+// operator, is taken greedily after '@', so "logger@1.4.2.js" is logger,
+// 1.4.2 and .js, and "express@^1.2" is a stack. Anything else after '@',
+// such as a tag, runs up to the extension.
 func ParseResourceName(resource string) (name, version, extension string) {
 	parts := strings.SplitN(resource, "@", 2)
 	nameWithExt := parts[0]
 
 	if len(parts) == 2 {
 		versionWithExt := parts[1]
+		numeric := specPrefixRe.FindString(versionWithExt)
+		rest := strings.TrimPrefix(versionWithExt, numeric)
 		// Check if version has extension
 		ext := filepath.Ext(versionWithExt)
-		if ext != "" {
+		if numeric != "" && (rest == "" || strings.HasPrefix(rest, ".")) {
+			version = numeric
+			extension = rest
+		} else if ext != "" {
 			version = strings.TrimSuffix(versionWithExt, ext)
 			extension = ext
 		} else {
@@ -229,6 +526,14 @@ func ParseResourceName(resource string) (name, version, extension string) {
 	return name, version, extension
 }
 
+// FormatResourceName joins a name, version and extension into a full name
+func FormatResourceName(name, version, extension string) string {
+	if version == "" {
+		return name + extension
+	}
+	return name + "@" + version + extension
+}
+
 func IsStack(resource string) bool {
 	_, _, ext := ParseResourceName(resource)
 	return ext == ""
@@ -237,3 +542,83 @@ func IsStack(resource string) bool {
 func IsSnippet(resource string) bool {
 	return !IsStack(resource)
 }
+
+// isLegacyValue reports whether a meta value uses the old name -> path format
+func isLegacyValue(value json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(value)
+	return len(trimmed) > 0 && trimmed[0] == '"'
+}
+
+// NewSnippetEntry builds an entry for a stored snippet file, filling in the
+// fields that can be derived from its full name and the file on disk
+func NewSnippetEntry(fullName, path string) (*SnippetEntry, error) {
+	name, version, ext := ParseResourceName(fullName)
+	entry := &SnippetEntry{
+		Name:      name,
+		Version:   version,
+		Extension: ext,
+		Language:  strings.TrimPrefix(ext, "."),
+		Path:      path,
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return entry, fmt.Errorf("failed to stat snippet: %w", err)
+	}
+	entry.Size = info.Size()
+
+	sum, err := FileChecksum(path)
+	if err != nil {
+		return entry, err
+	}
+	entry.Checksum = sum
+
+	return entry, nil
+}
+
+// NewStackEntry builds an entry for a stored stack directory, filling in the
+// fields that can be derived from its full name and the directory on disk
+func NewStackEntry(fullName, path string) (*StackEntry, error) {
+	name, version, _ := ParseResourceName(fullName)
+	entry := &StackEntry{
+		Name:    name,
+		Version: version,
+		Path:    path,
+	}
+
+	size, err := DirSize(path)
+	if err != nil {
+		return entry, fmt.Errorf("failed to measure stack: %w", err)
+	}
+	entry.Size = size
+
+	files, err := FileDigests(path)
+	if err != nil {
+		return entry, err
+	}
+	entry.Files = files
+	entry.Checksum = DigestRoot(files)
+
+	return entry, nil
+}
+
+// legacySnippetEntry converts an old name -> path meta record. Missing files
+// still produce an entry so that nothing is dropped during migration.
+func legacySnippetEntry(fullName, path string) *SnippetEntry {
+	entry, _ := NewSnippetEntry(fullName, path)
+	if info, err := os.Stat(path); err == nil {
+		entry.CreatedAt = info.ModTime()
+		entry.UpdatedAt = info.ModTime()
+	}
+	return entry
+}
+
+// legacyStackEntry converts an old name -> path meta record
+func legacyStackEntry(fullName, path string) *StackEntry {
+	entry, _ := NewStackEntry(fullName, path)
+	if info, err := os.Stat(path); err == nil {
+		entry.CreatedAt = info.ModTime()
+		entry.UpdatedAt = info.ModTime()
+	}
+	return entry
+}
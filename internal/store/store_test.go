@@ -0,0 +1,508 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+
+	const writers = 20
+	const perWriter = 5
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			// Each writer uses its own Store, like separate bl processes would
+			st := NewStore(dir)
+			if err := st.Load(); err != nil {
+				errs <- err
+				return
+			}
+			for i := 0; i < perWriter; i++ {
+				entry := &SnippetEntry{
+					Name:      fmt.Sprintf("snippet%d-%d", w, i),
+					Version:   "1",
+					Extension: ".js",
+				}
+				if err := st.AddSnippet(entry); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("writer failed: %v", err)
+	}
+
+	st := NewStore(dir)
+	if err := st.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := len(st.ListSnippets()); got != writers*perWriter {
+		t.Fatalf("got %d snippets, want %d", got, writers*perWriter)
+	}
+
+	// No temp files may be left behind by the atomic writes
+	leftovers, err := filepath.Glob(filepath.Join(dir, ".boiler.meta.json.*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) > 0 {
+		t.Fatalf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestLoadMigratesLegacyMeta(t *testing.T) {
+	dir := t.TempDir()
+
+	snippetPath := filepath.Join(dir, "snippets", "js", "logger@1.js")
+	if err := os.MkdirAll(filepath.Dir(snippetPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(snippetPath, []byte("console.log('hi')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := fmt.Sprintf(`{"stacks": {}, "snippets": {"logger@1.js": %q}}`, snippetPath)
+	if err := os.WriteFile(filepath.Join(dir, "boiler.meta.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st := NewStore(dir)
+	if err := st.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	entry, ok := st.GetSnippet("logger@1.js")
+	if !ok {
+		t.Fatal("migrated snippet not found")
+	}
+	if entry.Path != snippetPath || entry.Name != "logger" || entry.Version != "1" || entry.Extension != ".js" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if entry.Checksum == "" || entry.Size == 0 {
+		t.Fatalf("checksum and size not filled: %+v", entry)
+	}
+}
+
+func TestTxRollbackRestoresPrevious(t *testing.T) {
+	dir := t.TempDir()
+	st := NewStore(dir)
+
+	dest := filepath.Join(dir, "snippets", "js", "logger@1.js")
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := st.Begin()
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	staged := tx.StagePath("logger@1.js")
+	if err := os.MkdirAll(filepath.Dir(staged), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(staged, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Put(staged, dest); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "old" {
+		t.Fatalf("got %q after rollback, want %q", data, "old")
+	}
+}
+
+func TestTxMoveReturnsRenameErrors(t *testing.T) {
+	dir := t.TempDir()
+	st := NewStore(dir)
+
+	// Renaming onto a non-empty directory fails on the same file system,
+	// which must not be mistaken for a cross-device move
+	src := filepath.Join(dir, "stacks", "api@1")
+	dest := filepath.Join(dir, "trash", "api@1")
+	for _, path := range []string{filepath.Join(src, "main.go"), filepath.Join(dest, "other.go")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tx, err := st.Begin()
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.Move(src, dest); err == nil {
+		t.Fatal("Move() onto a non-empty directory succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(src, "main.go")); err != nil {
+		t.Errorf("source was touched after a failed move: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "main.go")); !os.IsNotExist(err) {
+		t.Errorf("source was copied into the destination after a failed move")
+	}
+}
+
+func TestRelativePathsAndRelocate(t *testing.T) {
+	base := t.TempDir()
+	oldRoot := filepath.Join(base, "old")
+	newRoot := filepath.Join(base, "new")
+
+	snippetPath := filepath.Join(oldRoot, "snippets", "js", "logger@1.js")
+	if err := os.MkdirAll(filepath.Dir(snippetPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(snippetPath, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st := NewStore(oldRoot)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := NewSnippetEntry("logger@1.js", snippetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddSnippet(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(oldRoot, "boiler.meta.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"path": "snippets/js/logger@1.js"`) {
+		t.Fatalf("meta does not contain a store-relative path:\n%s", data)
+	}
+
+	if err := st.Relocate(newRoot); err != nil {
+		t.Fatalf("Relocate() error = %v", err)
+	}
+
+	moved := NewStore(newRoot)
+	if err := moved.Load(); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := moved.GetSnippet("logger@1.js")
+	if !ok {
+		t.Fatal("snippet missing after relocate")
+	}
+	want := filepath.Join(newRoot, "snippets", "js", "logger@1.js")
+	if got.Path != want {
+		t.Fatalf("path = %s, want %s", got.Path, want)
+	}
+	if _, err := os.Stat(oldRoot); !os.IsNotExist(err) {
+		t.Fatalf("old store still exists: %v", err)
+	}
+}
+
+func TestMoveDirReturnsRenameErrors(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(base, "old")
+	dst := filepath.Join(base, "new")
+	for _, path := range []string{filepath.Join(src, "boiler.meta.json"), filepath.Join(dst, "other")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := moveDir(src, dst); err == nil {
+		t.Fatal("moveDir() onto a non-empty directory succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(src, "boiler.meta.json")); err != nil {
+		t.Errorf("source was removed after a failed move: %v", err)
+	}
+}
+
+func TestParseResourceName(t *testing.T) {
+	tests := []struct {
+		in                 string
+		name, version, ext string
+	}{
+		{"logger", "logger", "", ""},
+		{"logger.js", "logger", "", ".js"},
+		{"logger@1.js", "logger", "1", ".js"},
+		{"logger@1.4.2.js", "logger", "1.4.2", ".js"},
+		{"logger@1.4.js", "logger", "1.4", ".js"},
+		{"express@1", "express", "1", ""},
+		{"express@1.2.0", "express", "1.2.0", ""},
+		{"config@2.0.0.yaml", "config", "2.0.0", ".yaml"},
+		{"logger@^2.js", "logger", "^2", ".js"},
+		{"logger@>=1.4.js", "logger", ">=1.4", ".js"},
+		{"logger@stable.js", "logger", "stable", ".js"},
+		{"express@latest", "express", "latest", ""},
+	}
+
+	for _, tt := range tests {
+		name, version, ext := ParseResourceName(tt.in)
+		if name != tt.name || version != tt.version || ext != tt.ext {
+			t.Errorf("ParseResourceName(%q) = %q, %q, %q; want %q, %q, %q",
+				tt.in, name, version, ext, tt.name, tt.version, tt.ext)
+		}
+	}
+}
+
+func TestGetAllVersionsSemverOrder(t *testing.T) {
+	st := NewStore(t.TempDir())
+	for _, v := range []string{"1.10.0", "1", "1.9.2", "2.0.0"} {
+		st.meta.Snippets[FormatResourceName("logger", v, ".js")] = &SnippetEntry{Name: "logger", Version: v, Extension: ".js"}
+	}
+
+	got := st.GetAllVersions("logger", ".js")
+	want := []string{"1", "1.9.2", "1.10.0", "2.0.0"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("GetAllVersions() = %v, want %v", got, want)
+	}
+
+	next, err := st.GetNextVersion("logger", ".js", BumpMinor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != "2.1.0" {
+		t.Fatalf("GetNextVersion(minor) = %s, want 2.1.0", next)
+	}
+}
+
+func TestVersionSpellings(t *testing.T) {
+	for in, want := range map[string]string{"1": "1.0.0", "1.4": "1.4.0", " 1.4.2 ": "1.4.2"} {
+		if got, err := NormalizeVersion(in); err != nil || got != want {
+			t.Errorf("NormalizeVersion(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := NormalizeVersion("1.x"); err == nil {
+		t.Error("NormalizeVersion(1.x) succeeded")
+	}
+
+	// Entries an older store keyed by a short version are found by any spelling
+	st := NewStore(t.TempDir())
+	st.meta.Snippets["logger@1.js"] = &SnippetEntry{Name: "logger", Version: "1", Extension: ".js"}
+	st.meta.Stacks["express@2.1"] = &StackEntry{Name: "express", Version: "2.1"}
+	for _, name := range []string{"logger@1.js", "logger@1.0.js", "logger@1.0.0.js"} {
+		if e, ok := st.GetSnippet(name); !ok || e.Version != "1" {
+			t.Errorf("GetSnippet(%s) = %+v, %v; want the 1 entry", name, e, ok)
+		}
+	}
+	if !st.StackExists("express@2.1.0") || st.StackExists("express@2.1.1") || st.SnippetExists("logger@1.0.0.ts") {
+		t.Error("StackExists/SnippetExists do not compare versions by value")
+	}
+
+	if err := st.RemoveEntries([]string{"logger@1.0.0.js"}, []string{"express@2.1.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(st.ListSnippets()) != 0 || len(st.ListStacks()) != 0 {
+		t.Fatalf("entries left after RemoveEntries: %v %v", st.ListSnippets(), st.ListStacks())
+	}
+}
+
+func TestResolveConstraintsAndTags(t *testing.T) {
+	st := NewStore(t.TempDir())
+	for _, v := range []string{"1.0.0", "1.4.2", "2.0.0", "2.3.1", "3.0.0"} {
+		st.meta.Snippets[FormatResourceName("logger", v, ".js")] = &SnippetEntry{Name: "logger", Version: v, Extension: ".js"}
+	}
+	if err := st.SetTag(ResourceKey("logger", ".js"), "stable", "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"2":      "2.0.0",
+		"^2":     "2.3.1",
+		"~1.4":   "1.4.2",
+		">=2":    "3.0.0",
+		"<2":     "1.4.2",
+		"latest": "3.0.0",
+		"stable": "2.0.0",
+	}
+	for spec, want := range tests {
+		entry, err := st.ResolveSnippet("logger", ".js", spec)
+		if err != nil {
+			t.Errorf("ResolveSnippet(%q): %v", spec, err)
+			continue
+		}
+		if entry.Version != want {
+			t.Errorf("ResolveSnippet(%q) = %s, want %s", spec, entry.Version, want)
+		}
+	}
+
+	if _, err := st.ResolveSnippet("logger", ".js", "^4"); err == nil {
+		t.Error("ResolveSnippet(^4) succeeded, want error")
+	}
+	if err := st.SetTag(ResourceKey("logger", ".js"), TagLatest, "1.0.0"); err == nil {
+		t.Error("SetTag(latest) succeeded, want error")
+	}
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	root := t.TempDir()
+	st := NewStore(filepath.Join(root, "store"))
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(st.Root(), "snippets", "js", "logger@1.0.0.js")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := NewSnippetEntry("logger@1.0.0.js", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddSnippet(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetTag("logger.js", "stable", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	trash := NewTrash(filepath.Join(root, "trash"))
+	tx, err := st.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.MoveToTrash(tx, trash, []string{"logger@1.0.0.js"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if st.SnippetExists("logger@1.0.0.js") || fileExists(path) {
+		t.Fatal("snippet still in store after moving it to trash")
+	}
+
+	items, err := trash.Find("logger.js")
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Find() = %v, %v; want one item", items, err)
+	}
+	if err := st.Restore(trash, items[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if !st.SnippetExists("logger@1.0.0.js") || !fileExists(path) {
+		t.Fatal("snippet not restored")
+	}
+	if got := st.Tags("logger.js")["stable"]; got != "1.0.0" {
+		t.Fatalf("stable tag = %q after restore, want 1.0.0", got)
+	}
+	if left, _ := trash.List(); len(left) != 0 {
+		t.Fatalf("trash still holds %d item(s)", len(left))
+	}
+}
+
+func TestMergeMetaConflictTakesNewer(t *testing.T) {
+	base := `{"snippets": {"a@1.0.0.js": {"name": "a", "message": "base", "updatedAt": "2026-01-01T00:00:00Z"}}}`
+	ours := `{"snippets": {"a@1.0.0.js": {"name": "a", "message": "ours", "updatedAt": "2026-01-02T00:00:00Z"}}}`
+	theirs := `{"snippets": {"a@1.0.0.js": {"name": "a", "message": "theirs", "updatedAt": "2026-01-03T00:00:00Z"}}}`
+
+	merged, conflicts, err := MergeMeta([]byte(base), []byte(ours), []byte(theirs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0] != "a@1.0.0.js" {
+		t.Fatalf("conflicts = %v", conflicts)
+	}
+	if !strings.Contains(string(merged), `"theirs"`) {
+		t.Fatalf("newer entry did not win:\n%s", merged)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestVerifyStackReportsChangedFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "express@1.0.0")
+	for name, content := range map[string]string{"app.js": "app\n", "src/db.js": "db\n", "boiler.stack.json": "{}\n"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entry, err := NewStackEntry("express@1.0.0", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Files) != 3 || entry.Checksum != DigestRoot(entry.Files) {
+		t.Fatalf("entry digests = %v, checksum %s", entry.Files, entry.Checksum)
+	}
+	if sum, _ := DirChecksum(dir); sum != entry.Checksum {
+		t.Fatalf("DirChecksum() = %s, want %s", sum, entry.Checksum)
+	}
+	if err := VerifyStack(entry); err != nil {
+		t.Fatalf("VerifyStack() on untouched stack = %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "app.js"), []byte("edited\n"), 0644)
+	os.Remove(filepath.Join(dir, "src", "db.js"))
+	os.WriteFile(filepath.Join(dir, "extra.js"), []byte("x\n"), 0644)
+
+	err = VerifyStack(entry)
+	ierr, ok := err.(*IntegrityError)
+	if !ok {
+		t.Fatalf("VerifyStack() error = %v, want *IntegrityError", err)
+	}
+	if fmt.Sprint(ierr.Modified, ierr.Missing, ierr.Added) != "[app.js] [src/db.js] [extra.js]" {
+		t.Fatalf("IntegrityError = %+v", ierr)
+	}
+
+	// Entries from before per-file digests still catch changes as a whole
+	entry.Files = nil
+	if err := VerifyStack(entry); err == nil {
+		t.Fatal("VerifyStack() without digests passed a changed stack")
+	}
+}
+
+func TestVerifySnippet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logger@1.0.0.js")
+	os.WriteFile(path, []byte("log\n"), 0644)
+	entry, err := NewSnippetEntry("logger@1.0.0.js", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySnippet(entry); err != nil {
+		t.Fatalf("VerifySnippet() = %v", err)
+	}
+	os.WriteFile(path, []byte("tampered\n"), 0644)
+	if err := VerifySnippet(entry); err == nil {
+		t.Fatal("VerifySnippet() passed a changed file")
+	}
+}
@@ -0,0 +1,362 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Trash kinds
+const (
+	TrashSnippet = "snippet"
+	TrashStack   = "stack"
+)
+
+const trashItemFile = "item.json"
+
+// Trash holds removed snippets and stacks until they are restored or
+// purged. Every item is a directory with an item.json describing the
+// resource and the removed file or folder next to it.
+type Trash struct {
+	dir string
+}
+
+// TrashItem is one removed version of a snippet or stack. Entry paths are
+// kept relative to Store, the root of the store they were removed from.
+type TrashItem struct {
+	ID        string            `json:"id"`
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	Store     string            `json:"store,omitempty"`
+	Snippet   *SnippetEntry     `json:"snippet,omitempty"`
+	Stack     *StackEntry       `json:"stack,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	DeletedAt time.Time         `json:"deletedAt"`
+
+	dir string
+}
+
+// NewTrash returns the trash kept in dir
+func NewTrash(dir string) *Trash {
+	return &Trash{dir: dir}
+}
+
+// Dir returns the trash directory
+func (t *Trash) Dir() string {
+	return t.dir
+}
+
+// dataPath is where the removed file or folder is kept inside the item
+func (i *TrashItem) dataPath() string {
+	path := ""
+	if i.Snippet != nil {
+		path = i.Snippet.Path
+	} else if i.Stack != nil {
+		path = i.Stack.Path
+	}
+	return filepath.Join(i.dir, filepath.Base(filepath.FromSlash(path)))
+}
+
+// List returns all items in the trash, most recently removed first
+func (t *Trash) List() ([]*TrashItem, error) {
+	dirs, err := os.ReadDir(t.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+
+	var items []*TrashItem
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		item, err := t.load(d.Name())
+		if err != nil {
+			// A half-written item from an interrupted clean; skip it
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(a, b int) bool {
+		return items[a].DeletedAt.After(items[b].DeletedAt)
+	})
+	return items, nil
+}
+
+// Find returns the items matching a trash ID, a full name such as
+// logger@1.0.0.js, or a name without version such as logger.js, most
+// recently removed first
+func (t *Trash) Find(query string) ([]*TrashItem, error) {
+	items, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var exact, byKey []*TrashItem
+	for _, item := range items {
+		if item.ID == query || item.Name == query {
+			exact = append(exact, item)
+			continue
+		}
+		name, _, ext := ParseResourceName(item.Name)
+		if ResourceKey(name, ext) == query {
+			byKey = append(byKey, item)
+		}
+	}
+
+	if len(exact) > 0 {
+		return exact, nil
+	}
+	return byKey, nil
+}
+
+// Purge deletes items removed more than olderThan ago. Zero purges all.
+func (t *Trash) Purge(olderThan time.Duration) (int, error) {
+	items, err := t.List()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	purged := 0
+	for _, item := range items {
+		if olderThan > 0 && item.DeletedAt.After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(item.dir); err != nil {
+			return purged, fmt.Errorf("failed to purge '%s': %w", item.Name, err)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func (t *Trash) load(id string) (*TrashItem, error) {
+	dir := filepath.Join(t.dir, id)
+	data, err := os.ReadFile(filepath.Join(dir, trashItemFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var item TrashItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	item.dir = dir
+	return &item, nil
+}
+
+// add writes a new item and moves src into it as part of tx
+func (t *Trash) add(tx *Tx, item *TrashItem, src string) error {
+	id, err := newTrashID()
+	if err != nil {
+		return err
+	}
+	item.ID = id
+	item.DeletedAt = time.Now()
+	item.dir = filepath.Join(t.dir, id)
+
+	if err := os.MkdirAll(item.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create trash item: %w", err)
+	}
+	tx.created = append(tx.created, item.dir)
+
+	data, err := json.MarshalIndent(item, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trash item: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(item.dir, trashItemFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write trash item: %w", err)
+	}
+
+	return tx.Move(src, item.dataPath())
+}
+
+// newTrashID returns a sortable, unique item ID such as 20260101-120000-9f3a1c
+func newTrashID() (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to create trash id: %w", err)
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b), nil
+}
+
+// MoveToTrash moves the files of the given snippets and stacks into the
+// trash and drops their meta entries, as part of tx. Tags pointing at a
+// removed version are saved with it so restoring brings them back.
+func (s *Store) MoveToTrash(tx *Tx, trash *Trash, snippets, stacks []string) error {
+	for _, name := range snippets {
+		entry, ok := s.GetSnippet(name)
+		if !ok {
+			return fmt.Errorf("snippet '%s' not found", name)
+		}
+		e := *entry
+		e.Path = s.relativePath(entry.Path)
+		item := &TrashItem{
+			Kind:    TrashSnippet,
+			Name:    name,
+			Store:   s.root,
+			Snippet: &e,
+			Tags:    s.tagsFor(ResourceKey(e.Name, e.Extension), e.Version),
+		}
+		if err := trash.add(tx, item, entry.Path); err != nil {
+			return fmt.Errorf("failed to move snippet '%s' to trash: %w", name, err)
+		}
+	}
+
+	for _, name := range stacks {
+		entry, ok := s.GetStack(name)
+		if !ok {
+			return fmt.Errorf("stack '%s' not found", name)
+		}
+		e := *entry
+		e.Path = s.relativePath(entry.Path)
+		item := &TrashItem{
+			Kind:  TrashStack,
+			Name:  name,
+			Store: s.root,
+			Stack: &e,
+			Tags:  s.tagsFor(ResourceKey(e.Name, ""), e.Version),
+		}
+		if err := trash.add(tx, item, entry.Path); err != nil {
+			return fmt.Errorf("failed to move stack '%s' to trash: %w", name, err)
+		}
+	}
+
+	return s.RemoveEntries(snippets, stacks)
+}
+
+// Restore puts a trashed item back in the store under its old path and
+// name, with the tags it had unless they were moved since. It fails if the
+// version was stored again in the meantime.
+func (s *Store) Restore(trash *Trash, item *TrashItem) error {
+	var rel string
+	switch {
+	case item.Snippet != nil:
+		if s.SnippetExists(item.Name) {
+			return fmt.Errorf("snippet '%s' already exists in the store", item.Name)
+		}
+		rel = item.Snippet.Path
+	case item.Stack != nil:
+		if s.StackExists(item.Name) {
+			return fmt.Errorf("stack '%s' already exists in the store", item.Name)
+		}
+		rel = item.Stack.Path
+	default:
+		return fmt.Errorf("trash item '%s' has no entry", item.ID)
+	}
+
+	dest := s.resolvePath(rel)
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("'%s' already exists", dest)
+	}
+
+	tx, err := s.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.Move(item.dataPath(), dest); err != nil {
+		return fmt.Errorf("failed to restore '%s': %w", item.Name, err)
+	}
+
+	err = s.Update(func() error {
+		key := ""
+		version := ""
+		if item.Snippet != nil {
+			e := *item.Snippet
+			e.Path = dest
+			s.meta.Snippets[item.Name] = &e
+			key, version = ResourceKey(e.Name, e.Extension), e.Version
+		} else {
+			e := *item.Stack
+			e.Path = dest
+			s.meta.Stacks[item.Name] = &e
+			key, version = ResourceKey(e.Name, ""), e.Version
+		}
+
+		for tag := range item.Tags {
+			if _, taken := s.meta.Tags[key][tag]; taken {
+				continue
+			}
+			if s.meta.Tags == nil {
+				s.meta.Tags = make(map[string]map[string]string)
+			}
+			if s.meta.Tags[key] == nil {
+				s.meta.Tags[key] = make(map[string]string)
+			}
+			s.meta.Tags[key][tag] = version
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(item.dir); err != nil {
+		return fmt.Errorf("restored, but failed to remove trash item: %w", err)
+	}
+	return nil
+}
+
+// tagsFor returns the tags of key that point at version
+func (s *Store) tagsFor(key, version string) map[string]string {
+	var tags map[string]string
+	for tag, v := range s.meta.Tags[key] {
+		if v != version {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[tag] = v
+	}
+	return tags
+}
+
+// Age formats how long ago an item was removed, e.g. 3d or 5h
+func (i *TrashItem) Age() string {
+	d := time.Since(i.DeletedAt)
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// ParseAge parses an age such as 30d, 2w, 12h or any Go duration
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid age '%s', use e.g. 30d, 2w or 12h", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age '%s', use e.g. 30d, 2w or 12h", s)
+	}
+	return d, nil
+}
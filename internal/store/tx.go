@@ -0,0 +1,168 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// Tx groups file system changes to the store so they can be committed or
+// rolled back as a unit. New content is staged in a temp area under the
+// store root and swapped in with renames; replaced and removed paths are
+// moved aside and only deleted on Commit, so Rollback can put them back.
+type Tx struct {
+	dir     string
+	moves   []txMove
+	created []string
+	seq     int
+	done    bool
+}
+
+// txMove records a rename that Rollback has to reverse
+type txMove struct {
+	from string
+	to   string
+}
+
+// Begin starts a transaction with its own temp area under <store>/.tx
+func (s *Store) Begin() (*Tx, error) {
+	base := filepath.Join(s.root, ".tx")
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create transaction directory: %w", err)
+	}
+
+	dir, err := os.MkdirTemp(base, "tx-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction directory: %w", err)
+	}
+
+	return &Tx{dir: dir}, nil
+}
+
+// StagePath returns a fresh path inside the temp area where the caller can
+// write new content before handing it to Put
+func (t *Tx) StagePath(name string) string {
+	return filepath.Join(t.dir, t.next("stage"), name)
+}
+
+// Put moves staged content to dest. Anything already at dest is kept aside
+// until Commit.
+func (t *Tx) Put(staged, dest string) error {
+	if err := t.Remove(dest); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	if err := t.move(staged, dest); err != nil {
+		return fmt.Errorf("failed to move staged content into place: %w", err)
+	}
+
+	return nil
+}
+
+// Remove moves dest out of the store. It is deleted on Commit and restored
+// on Rollback. A missing dest is not an error.
+func (t *Tx) Remove(dest string) error {
+	if _, err := os.Lstat(dest); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to stat %s: %w", dest, err)
+	}
+
+	backup := filepath.Join(t.dir, t.next("backup"), filepath.Base(dest))
+	if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := t.move(dest, backup); err != nil {
+		return fmt.Errorf("failed to move %s aside: %w", dest, err)
+	}
+
+	return nil
+}
+
+// Move moves src to dest, typically out of the store, e.g. into the trash.
+// Rollback moves it back. Only across file systems is src copied, and the
+// original is kept aside until Commit.
+func (t *Tx) Move(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	err := t.move(src, dest)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := copyTree(src, dest); err != nil {
+		os.RemoveAll(dest)
+		return err
+	}
+	t.created = append(t.created, dest)
+	return t.Remove(src)
+}
+
+// Commit makes the changes permanent by deleting the temp area
+func (t *Tx) Commit() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+
+	if err := os.RemoveAll(t.dir); err != nil {
+		return fmt.Errorf("failed to clean up transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback reverses every move in the opposite order. It is a no-op after
+// Commit, so it can always be deferred.
+func (t *Tx) Rollback() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+
+	var errs []error
+	for i := len(t.moves) - 1; i >= 0; i-- {
+		m := t.moves[i]
+		if err := os.Rename(m.to, m.from); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", m.from, err))
+		}
+	}
+	for i := len(t.created) - 1; i >= 0; i-- {
+		if err := os.RemoveAll(t.created[i]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %s: %w", t.created[i], err))
+		}
+	}
+
+	// Keep the temp area if anything could not be restored, it may hold
+	// the only copy of the previous version
+	if len(errs) > 0 {
+		errs = append(errs, fmt.Errorf("transaction data kept in %s", t.dir))
+		return errors.Join(errs...)
+	}
+
+	return os.RemoveAll(t.dir)
+}
+
+func (t *Tx) move(from, to string) error {
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	t.moves = append(t.moves, txMove{from: from, to: to})
+	return nil
+}
+
+func (t *Tx) next(kind string) string {
+	t.seq++
+	return kind + "-" + strconv.Itoa(t.seq)
+}
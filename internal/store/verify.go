@@ -0,0 +1,96 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IntegrityError reports a stored resource whose files no longer match the
+// digests recorded when it was stored
+type IntegrityError struct {
+	// Type is either "snippet" or "stack"
+	Type string
+	Name string
+	// Modified, Missing and Added list the affected files of a stack. They
+	// are empty for snippets and for stacks stored without per-file digests.
+	Modified []string
+	Missing  []string
+	Added    []string
+}
+
+func (e *IntegrityError) Error() string {
+	var parts []string
+	for _, group := range []struct {
+		label string
+		files []string
+	}{{"modified", e.Modified}, {"missing", e.Missing}, {"added", e.Added}} {
+		if len(group.files) > 0 {
+			parts = append(parts, group.label+": "+strings.Join(group.files, ", "))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%s '%s' does not match the checksum recorded when it was stored", e.Type, e.Name)
+	}
+	return fmt.Sprintf("%s '%s' changed since it was stored (%s)", e.Type, e.Name, strings.Join(parts, "; "))
+}
+
+// VerifySnippet checks a snippet file against the checksum in its entry.
+// Entries without a checksum pass. A mismatch is an *IntegrityError.
+func VerifySnippet(entry *SnippetEntry) error {
+	if entry.Checksum == "" {
+		return nil
+	}
+	sum, err := FileChecksum(entry.Path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sum, entry.Checksum) {
+		return &IntegrityError{Type: "snippet", Name: entry.FullName()}
+	}
+	return nil
+}
+
+// VerifyStack checks every file of a stack against the digests in its entry
+// and the stack as a whole against its checksum. Entries without digests
+// pass. A mismatch is an *IntegrityError.
+func VerifyStack(entry *StackEntry) error {
+	if entry.Checksum == "" && len(entry.Files) == 0 {
+		return nil
+	}
+	files, err := FileDigests(entry.Path)
+	if err != nil {
+		return err
+	}
+
+	ierr := &IntegrityError{Type: "stack", Name: entry.FullName()}
+	if len(entry.Files) > 0 {
+		for p, want := range entry.Files {
+			got, ok := files[p]
+			switch {
+			case !ok:
+				ierr.Missing = append(ierr.Missing, p)
+			case !strings.EqualFold(got, want):
+				ierr.Modified = append(ierr.Modified, p)
+			}
+		}
+		for p := range files {
+			if _, ok := entry.Files[p]; !ok {
+				ierr.Added = append(ierr.Added, p)
+			}
+		}
+		sort.Strings(ierr.Modified)
+		sort.Strings(ierr.Missing)
+		sort.Strings(ierr.Added)
+		if len(ierr.Modified)+len(ierr.Missing)+len(ierr.Added) > 0 {
+			return ierr
+		}
+	}
+
+	// The checksum must also agree with the digests, or the index itself
+	// was edited
+	if entry.Checksum != "" && !strings.EqualFold(DigestRoot(files), entry.Checksum) {
+		return ierr
+	}
+	return nil
+}
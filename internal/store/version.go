@@ -0,0 +1,152 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version. Versions with fewer than three parts, such
+// as the plain integers used by older stores, are padded with zeros, so
+// "2" and "2.0.0" compare equal.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Bump kinds accepted by Version.Bump
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+)
+
+// versionPrefixRe matches a version of one to three numeric parts at the
+// start of the text following '@' in a resource name
+var versionPrefixRe = regexp.MustCompile(`^\d+(?:\.\d+){0,2}`)
+
+// ParseVersion parses "1", "1.4" or "1.4.2"
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	s = strings.TrimSpace(s)
+	if s == "" || versionPrefixRe.FindString(s) != s {
+		return v, fmt.Errorf("invalid version '%s': expected MAJOR[.MINOR[.PATCH]]", s)
+	}
+
+	parts := strings.Split(s, ".")
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, fmt.Errorf("invalid version '%s': %w", s, err)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// NormalizeVersion returns the canonical MAJOR.MINOR.PATCH form of a
+// version, so "1" and "1.0" both become "1.0.0". New entries are stored
+// under it, so one version can't end up in the index twice.
+func NormalizeVersion(s string) (string, error) {
+	v, err := ParseVersion(s)
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or higher than o
+func (v Version) Compare(o Version) int {
+	switch {
+	case v.Major != o.Major:
+		return cmpInt(v.Major, o.Major)
+	case v.Minor != o.Minor:
+		return cmpInt(v.Minor, o.Minor)
+	default:
+		return cmpInt(v.Patch, o.Patch)
+	}
+}
+
+// Bump returns the next version for the given kind (major, minor or patch)
+func (v Version) Bump(kind string) (Version, error) {
+	switch strings.ToLower(kind) {
+	case BumpMajor:
+		return Version{Major: v.Major + 1}, nil
+	case BumpMinor:
+		return Version{Major: v.Major, Minor: v.Minor + 1}, nil
+	case BumpPatch, "":
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}, nil
+	}
+	return v, fmt.Errorf("invalid bump '%s'. Use major, minor or patch", kind)
+}
+
+// SameVersion reports whether two version strings name the same version,
+// such as "1" and "1.0.0"
+func SameVersion(a, b string) bool {
+	va, err := ParseVersion(a)
+	if err != nil {
+		return false
+	}
+	vb, err := ParseVersion(b)
+	return err == nil && va.Compare(vb) == 0
+}
+
+// CompareVersions orders two version strings semantically. Strings that are
+// not valid versions sort before valid ones and among themselves by text.
+func CompareVersions(a, b string) int {
+	va, errA := ParseVersion(a)
+	vb, errB := ParseVersion(b)
+	switch {
+	case errA == nil && errB == nil:
+		if c := va.Compare(vb); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// SortVersions sorts version strings in ascending semantic order
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return CompareVersions(versions[i], versions[j]) < 0
+	})
+}
+
+// sortResourceNames sorts full names by name and extension, then by version
+func sortResourceNames(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		ni, vi, ei := ParseResourceName(names[i])
+		nj, vj, ej := ParseResourceName(names[j])
+		if ni != nj {
+			return ni < nj
+		}
+		if ei != ej {
+			return ei < ej
+		}
+		return CompareVersions(vi, vj) < 0
+	})
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Reserved variables boiler fills in itself. A snippet uses them without
+// declaring them; declaring one turns its value into the prompt's default.
+const (
+	BuiltinYear       = "bl__YEAR"
+	BuiltinDate       = "bl__DATE"
+	BuiltinGitUser    = "bl__GIT_USER"
+	BuiltinGitEmail   = "bl__GIT_EMAIL"
+	BuiltinDir        = "bl__DIR"
+	BuiltinGoModule   = "bl__GO_MODULE"
+	BuiltinNpmPackage = "bl__NPM_PACKAGE"
+	BuiltinUUID       = "bl__UUID"
+)
+
+// builtins work out a reserved variable for files added to dir
+var builtins = map[string]func(dir string) (string, error){
+	BuiltinYear: func(string) (string, error) { return time.Now().Format("2006"), nil },
+	BuiltinDate: func(string) (string, error) { return time.Now().Format("2006-01-02"), nil },
+	BuiltinGitUser: func(dir string) (string, error) {
+		return gitValue(dir, "user.name")
+	},
+	BuiltinGitEmail: func(dir string) (string, error) {
+		return gitValue(dir, "user.email")
+	},
+	BuiltinDir: func(dir string) (string, error) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Base(abs), nil
+	},
+	BuiltinGoModule:   goModule,
+	BuiltinNpmPackage: npmPackage,
+	BuiltinUUID:       newUUID,
+}
+
+var builtinRe = regexp.MustCompile(`\b(` + strings.Join(BuiltinNames(), "|") + `)\b`)
+
+// BuiltinNames returns the reserved variable names, sorted
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBuiltin reports whether name is a reserved variable
+func IsBuiltin(name string) bool {
+	_, ok := builtins[name]
+	return ok
+}
+
+// BuiltinValue works out a reserved variable for files added to dir. The
+// error says why a value could not be found, e.g. no go.mod.
+func BuiltinValue(name, dir string) (string, error) {
+	fn, ok := builtins[name]
+	if !ok {
+		return "", fmt.Errorf("%s is not a reserved variable", name)
+	}
+	return fn(dir)
+}
+
+func gitValue(dir, key string) (string, error) {
+	// The destination may not exist yet; ask from the nearest directory that does
+	for dir != "" && !IsDirectory(dir) {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	if value := gitConfig(dir, key); value != "" {
+		return value, nil
+	}
+	return "", fmt.Errorf("git config %s is not set", key)
+}
+
+// findUp returns the first file called name in dir or above it
+func findUp(dir, name string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(abs, name)
+		if FileExists(path) {
+			return path, nil
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", fmt.Errorf("no %s found in %s or above", name, dir)
+		}
+		abs = parent
+	}
+}
+
+// goModule reads the module path from the nearest go.mod
+func goModule(dir string) (string, error) {
+	path, err := findUp(dir, "go.mod")
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`+"`"), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no module line", path)
+}
+
+// npmPackage reads the package name from the nearest package.json
+func npmPackage(dir string) (string, error) {
+	path, err := findUp(dir, "package.json")
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var pkg struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if pkg.Name == "" {
+		return "", fmt.Errorf("%s has no name", path)
+	}
+	return pkg.Name, nil
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID(string) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestBuiltinValues(t *testing.T) {
+	root := t.TempDir()
+	dest := filepath.Join(root, "web", "src", "user-service")
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"go.mod":           "module github.com/acme/shop\n\ngo 1.22\n",
+		"web/package.json": `{"name": "@acme/web", "version": "1.0.0"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string]string{
+		BuiltinDir:        "user-service",
+		BuiltinGoModule:   "github.com/acme/shop",
+		BuiltinNpmPackage: "@acme/web",
+	} {
+		got, err := BuiltinValue(name, dest)
+		if err != nil || got != want {
+			t.Errorf("BuiltinValue(%s) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+
+	uuid, err := BuiltinValue(BuiltinUUID, dest)
+	if err != nil || !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid) {
+		t.Errorf("BuiltinValue(%s) = %q, %v; want a version 4 UUID", BuiltinUUID, uuid, err)
+	}
+	if _, err := BuiltinValue(BuiltinGoModule, t.TempDir()); err == nil {
+		t.Errorf("BuiltinValue(%s) found a go.mod outside the project", BuiltinGoModule)
+	}
+}
+
+func TestComputedDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.go")
+	content := "// __author me\n" +
+		"// __var bl__ENTITY = user profile\n" +
+		"// __var bl__TABLE = {{bl__ENTITY|plural|snake}}\n" +
+		"// __var bl__ROUTE:string(/^[a-z-]+$/) = {{ bl__ENTITY | kebab }}\n" +
+		"// __var bl__NOTICE = (c) {{bl__YEAR}} {{bl__GIT_USER}}\n" +
+		"// __var bl__LATE = {{bl__LATER}}\n" +
+		"// __var bl__LATER = x\n" +
+		"package bl__GO_MODULE // bl__UUID, bl__DIR_NAME\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := ParseSnippetMetadata(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := meta.CheckVariables(); err == nil || !regexp.MustCompile(`bl__LATE: default uses bl__LATER`).MatchString(err.Error()) {
+		t.Errorf("CheckVariables() = %v, want an error for bl__LATE", err)
+	}
+	slices.Sort(meta.Builtins)
+	if want := []string{BuiltinGitUser, BuiltinGoModule, BuiltinUUID, BuiltinYear}; !slices.Equal(meta.Builtins, want) {
+		t.Errorf("Builtins = %v, want %v", meta.Builtins, want)
+	}
+
+	values := map[string]string{"bl__ENTITY": "UserProfile", BuiltinYear: "2026", BuiltinGitUser: "Ada"}
+	for _, v := range meta.Variables {
+		values[v.Name] = v.ExpandDefault(values)
+	}
+	for name, want := range map[string]string{
+		"bl__TABLE":  "user_profiles",
+		"bl__ROUTE":  "user-profile",
+		"bl__NOTICE": "(c) 2026 Ada",
+	} {
+		if values[name] != want {
+			t.Errorf("%s = %q, want %q", name, values[name], want)
+		}
+	}
+
+	if _, _, err := ParseVariable("// __var bl__X = {{bl__ENTITY|shout}}"); err == nil {
+		t.Error("ParseVariable accepted an unknown transform in a computed default")
+	}
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Directives select which lines of a snippet are rendered. They sit in
+// comments and are dropped from the output like __var lines:
+//
+//	// __if bl__USE_REDIS
+//	// __elif bl__DB == postgres|mysql
+//	// __else
+//	// __endif
+//
+// A bare variable is true when its value is true, or not empty and not a
+// false word for other types; "!" negates it. == and != compare the value
+// with one or more options, which suits enum variables. Blocks nest.
+const (
+	DirectiveIf    = "if"
+	DirectiveElif  = "elif"
+	DirectiveElse  = "else"
+	DirectiveEndif = "endif"
+)
+
+var (
+	directiveRe = regexp.MustCompile(`^\s*[/#;*<!-]*\s*__(if|elif|else|endif)(?:\s+(.*?))?\s*(?:\*/|-->)?\s*$`)
+	varNameRe   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// ParseDirective returns the directive on line and its condition, or false
+// if the line holds none
+func ParseDirective(line string) (kind, expr string, ok bool) {
+	m := directiveRe.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// Condition is the test of an __if or __elif directive
+type Condition struct {
+	Name    string
+	Negate  bool     // !NAME, or != with Options
+	Options []string // compared with ==/!=; empty for a bare variable
+}
+
+// ParseCondition parses NAME, !NAME, NAME == a|b or NAME != a|b
+func ParseCondition(expr string) (Condition, error) {
+	var c Condition
+	name := strings.TrimSpace(expr)
+	for _, op := range []string{"!=", "=="} {
+		left, right, ok := strings.Cut(expr, op)
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(left)
+		c.Negate = op == "!="
+		for _, opt := range strings.Split(right, "|") {
+			if opt = strings.TrimSpace(opt); opt != "" {
+				c.Options = append(c.Options, opt)
+			}
+		}
+		if len(c.Options) == 0 {
+			return c, fmt.Errorf("'%s': nothing to compare with after %s", expr, op)
+		}
+		break
+	}
+	if c.Options == nil && strings.HasPrefix(name, "!") {
+		c.Negate = true
+		name = strings.TrimSpace(name[1:])
+	}
+	if !varNameRe.MatchString(name) {
+		return c, fmt.Errorf("'%s' is not a condition, expected NAME, !NAME or NAME == value", expr)
+	}
+	c.Name = name
+	return c, nil
+}
+
+// Eval tests the condition against a variable's value
+func (c Condition) Eval(value string) bool {
+	result := false
+	if len(c.Options) == 0 {
+		switch strings.ToLower(value) {
+		case "", "false", "no", "n", "0", "off":
+		default:
+			result = true
+		}
+	} else {
+		for _, opt := range c.Options {
+			if strings.EqualFold(opt, value) {
+				result = true
+				break
+			}
+		}
+	}
+	return result != c.Negate
+}
+
+// block is an __if block that is open at the current line
+type block struct {
+	line   int  // line of the __if
+	outer  bool // whether the lines around the block are rendered
+	taken  bool // whether an earlier branch was rendered
+	active bool // whether the current branch is rendered
+	inElse bool
+}
+
+// blocks follows the __if blocks through a file
+type blocks struct {
+	open []block
+}
+
+// rendering reports whether the current line is rendered
+func (b *blocks) rendering() bool {
+	return len(b.open) == 0 || b.open[len(b.open)-1].active
+}
+
+// apply takes one directive. test decides a condition; conditions are only
+// parsed when it is nil.
+func (b *blocks) apply(kind, expr string, line int, test func(Condition) (bool, error)) error {
+	decide := func() (bool, error) {
+		c, err := ParseCondition(expr)
+		if err != nil || test == nil {
+			return false, err
+		}
+		return test(c)
+	}
+
+	switch kind {
+	case DirectiveIf:
+		outer := b.rendering()
+		ok, err := decide()
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		b.open = append(b.open, block{line: line, outer: outer, taken: ok, active: outer && ok})
+		return nil
+	}
+
+	if len(b.open) == 0 {
+		return fmt.Errorf("line %d: __%s without __if", line, kind)
+	}
+	top := &b.open[len(b.open)-1]
+	switch kind {
+	case DirectiveElif:
+		if top.inElse {
+			return fmt.Errorf("line %d: __elif after __else", line)
+		}
+		ok, err := decide()
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		top.active = top.outer && ok && !top.taken
+		top.taken = top.taken || ok
+	case DirectiveElse:
+		if top.inElse {
+			return fmt.Errorf("line %d: second __else", line)
+		}
+		if strings.TrimSpace(expr) != "" {
+			return fmt.Errorf("line %d: __else takes no condition", line)
+		}
+		top.inElse = true
+		top.active = top.outer && !top.taken
+		top.taken = true
+	case DirectiveEndif:
+		b.open = b.open[:len(b.open)-1]
+	}
+	return nil
+}
+
+// end reports an __if that was never closed
+func (b *blocks) end() error {
+	if len(b.open) > 0 {
+		return fmt.Errorf("line %d: __if without __endif", b.open[len(b.open)-1].line)
+	}
+	return nil
+}
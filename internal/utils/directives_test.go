@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cacheSnippet = `// __author me
+// __var bl__USE_REDIS:bool = false
+// __var bl__DB:enum(postgres|mysql|sqlite) = postgres
+// __if bl__USE_REDIS
+const cache = redis();
+// __else
+const cache = memory();
+// __endif
+/* __if bl__DB == postgres|mysql */
+// __if bl__DB != mysql
+const driver = "pg";
+// __else
+const driver = "mysql";
+// __endif
+// __elif !bl__USE_REDIS
+const file = "app.db";
+/* __endif */
+done();
+`
+
+func TestRenderDirectives(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.js")
+	if err := os.WriteFile(path, []byte(cacheSnippet), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		vars map[string]string
+		want string
+	}{
+		{map[string]string{"bl__USE_REDIS": "true", "bl__DB": "postgres"},
+			"const cache = redis();\nconst driver = \"pg\";\ndone();\n"},
+		{map[string]string{"bl__USE_REDIS": "false", "bl__DB": "mysql"},
+			"const cache = memory();\nconst driver = \"mysql\";\ndone();\n"},
+		{map[string]string{"bl__USE_REDIS": "false", "bl__DB": "sqlite"},
+			"const cache = memory();\nconst file = \"app.db\";\ndone();\n"},
+		{map[string]string{"bl__USE_REDIS": "true", "bl__DB": "sqlite"},
+			"const cache = redis();\ndone();\n"},
+	}
+	for _, tt := range tests {
+		got, err := RenderFileWithVariables(path, tt.vars)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.vars, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%v: rendered\n%s\nwant\n%s", tt.vars, got, tt.want)
+		}
+	}
+}
+
+func TestDirectiveErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"unclosed":   "// __var bl__X:bool = true\n// __if bl__X\nx\n",
+		"stray":      "// __var bl__X:bool = true\n// __endif\n",
+		"undeclared": "// __if bl__MISSING\nx\n// __endif\n",
+		"option":     "// __var bl__DB:enum(pg|mysql) = pg\n// __if bl__DB == postgres\nx\n// __endif\n",
+		"else twice": "// __var bl__X:bool = true\n// __if bl__X\n// __else\n// __else\n// __endif\n",
+		"condition":  "// __var bl__X:bool = true\n// __if bl__X ==\n// __endif\n",
+	}
+	for name, content := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".js")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		meta, err := ParseSnippetMetadata(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := meta.CheckVariables(); err == nil || !strings.Contains(err.Error(), "invalid __if block") {
+			t.Errorf("%s: CheckVariables() = %v, want an __if block error", name, err)
+		}
+	}
+}
+
+func TestParseDirective(t *testing.T) {
+	for line, want := range map[string]string{
+		"// __if bl__X":             "if bl__X",
+		"# __elif bl__DB == pg":     "elif bl__DB == pg",
+		"<!-- __if !bl__X -->":      "if !bl__X",
+		"-- __else":                 "else ",
+		"  /* __endif */":           "endif ",
+		"const __iffy = 1":          "",
+		"// see __if bl__X in docs": "",
+	} {
+		kind, expr, ok := ParseDirective(line)
+		got := ""
+		if ok {
+			got = kind + " " + expr
+		}
+		if got != want {
+			t.Errorf("ParseDirective(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
@@ -2,12 +2,12 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
-	"strings"
 )
 
 func CopyFile(src, dst string) error {
@@ -61,7 +61,7 @@ func CopyDir(src, dst string, ignorePatterns []string) error {
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
 
-		if shouldIgnore(entry.Name(), ignorePatterns) {
+		if ShouldIgnore(entry.Name(), ignorePatterns) {
 			continue
 		}
 
@@ -79,7 +79,9 @@ func CopyDir(src, dst string, ignorePatterns []string) error {
 	return nil
 }
 
-func shouldIgnore(name string, patterns []string) bool {
+// ShouldIgnore reports whether a file or directory name matches one of the
+// ignore patterns
+func ShouldIgnore(name string, patterns []string) bool {
 	for _, pattern := range patterns {
 		if name == pattern {
 			return true
@@ -115,65 +117,92 @@ func GetFileNameWithoutExt(filename string) string {
 }
 
 // CopyFileWithVariables copies a file and replaces template variables
-// Variables format: bl__VAR_NAME will be replaced with provided values
-// Also removes all metadata comments (__author, __desc, __var lines)
+// Variables format: bl__VAR_NAME will be replaced with provided values,
+// bl__VAR_NAME|pascal and other Transforms with the transformed value
+// Also removes all metadata comments (__author, __desc, __var lines) and
+// renders only the lines __if/__else blocks select
 func CopyFileWithVariables(src, dst string, varReplacements map[string]string) error {
-	sourceFile, err := os.Open(src)
+	content, err := RenderFileWithVariables(src, varReplacements)
 	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+		return err
 	}
-	defer sourceFile.Close()
 
 	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
+	if err := os.WriteFile(dst, content, 0644); err != nil {
+		return fmt.Errorf("failed to write destination file: %w", err)
+	}
 
-	destFile, err := os.Create(dst)
+	// Copy file permissions
+	sourceInfo, err := os.Stat(src)
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		return fmt.Errorf("failed to stat source file: %w", err)
+	}
+	if err := os.Chmod(dst, sourceInfo.Mode()); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
 	}
-	defer destFile.Close()
 
-	// Regex to match metadata lines
-	metadataRe := regexp.MustCompile(`^\s*[/#;-]*\s*__(?:author|desc|version|var)\s+.+`)
+	return nil
+}
 
+// RenderFileWithVariables returns the content CopyFileWithVariables writes
+// for src: __if blocks evaluated, variables replaced and metadata comments
+// and directives removed
+func RenderFileWithVariables(src string, varReplacements map[string]string) ([]byte, error) {
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer sourceFile.Close()
+
+	// Regex to match metadata lines
+	metadataRe := regexp.MustCompile(`^\s*[/#;*<!-]*\s*__(?:author|desc|version|var)\s+.+`)
+
+	var out bytes.Buffer
+	var b blocks
+	replacer := newVariableReplacer(varReplacements)
+	test := func(c Condition) (bool, error) {
+		value, ok := varReplacements[c.Name]
+		if !ok {
+			return false, fmt.Errorf("%s has no value", c.Name)
+		}
+		return c.Eval(value), nil
+	}
 	scanner := bufio.NewScanner(sourceFile)
-	writer := bufio.NewWriter(destFile)
 
-	for scanner.Scan() {
+	for n := 1; scanner.Scan(); n++ {
 		line := scanner.Text()
 
-		// Skip metadata comment lines
-		if metadataRe.MatchString(line) {
+		// Evaluate directives and skip the lines they leave out
+		if kind, expr, ok := ParseDirective(line); ok {
+			if err := b.apply(kind, expr, n, test); err != nil {
+				return nil, fmt.Errorf("%s: %w", src, err)
+			}
 			continue
 		}
-
-		// Replace variables (bl__VAR_NAME -> actual value)
-		for varName, varValue := range varReplacements {
-			line = strings.ReplaceAll(line, varName, varValue)
+		if !b.rendering() {
+			continue
 		}
 
-		if _, err := writer.WriteString(line + "\n"); err != nil {
-			return fmt.Errorf("failed to write line: %w", err)
+		// Skip metadata comment lines
+		if metadataRe.MatchString(line) {
+			continue
 		}
-	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
+		// Replace variables (bl__VAR_NAME -> actual value, bl__VAR_NAME|kebab
+		// -> transformed value)
+		line = replacer.replace(line)
 
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush writer: %w", err)
+		out.WriteString(line + "\n")
 	}
 
-	// Copy file permissions
-	sourceInfo, err := os.Stat(src)
-	if err != nil {
-		return fmt.Errorf("failed to stat source file: %w", err)
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
-	if err := os.Chmod(dst, sourceInfo.Mode()); err != nil {
-		return fmt.Errorf("failed to set file permissions: %w", err)
+	if err := b.end(); err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
 	}
 
-	return nil
+	return out.Bytes(), nil
 }
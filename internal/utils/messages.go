@@ -2,12 +2,14 @@ package utils
 
 const (
 	// Success messages
-	MsgSnippetStored  = "✓ Stored snippet '%s' at %s\n"
-	MsgStackStored    = "✓ Stored stack '%s' at %s\n"
-	MsgSnippetAdded   = "✓ Added snippet '%s' to %s\n"
-	MsgStackAdded     = "✓ Added stack '%s' to %s\n"
-	MsgSnippetRemoved = "✓ Removed snippet '%s'\n"
-	MsgStackRemoved   = "✓ Removed stack '%s'\n"
+	MsgSnippetStored   = "✓ Stored snippet '%s' at %s\n"
+	MsgStackStored     = "✓ Stored stack '%s' at %s\n"
+	MsgSnippetAdded    = "✓ Added snippet '%s' to %s\n"
+	MsgStackAdded      = "✓ Added stack '%s' to %s\n"
+	MsgSnippetRemoved  = "✓ Moved snippet '%s' to trash\n"
+	MsgStackRemoved    = "✓ Moved stack '%s' to trash\n"
+	MsgSnippetRestored = "✓ Restored snippet '%s'\n"
+	MsgStackRestored   = "✓ Restored stack '%s'\n"
 
 	// Warning messages
 	MsgWarningSnippetExists = "⚠ Warning: snippet '%s' already exists in store"
@@ -32,4 +34,6 @@ const (
 	MsgNoSnippets      = "No snippets to clean"
 	MsgNoStacks        = "No stacks to clean"
 	MsgNoResourcesFound = "No resources found in store"
+	MsgTrashHint        = "Run 'bl restore <name>' to bring it back, 'bl trash ls' to see the trash"
+	MsgTrashEmpty       = "Trash is empty"
 )
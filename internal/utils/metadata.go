@@ -2,10 +2,12 @@ package utils
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 )
@@ -23,7 +25,37 @@ type CommonMetadata struct {
 type SnippetMetadata struct {
 	CommonMetadata
 	Language  string
-	Variables map[string]string // bl__VAR_NAME -> default value
+	Variables []Variable // in declaration order
+	Builtins  []string   // reserved variables used without a declaration
+
+	// varErrors holds malformed __var declarations and blockErrors
+	// malformed __if blocks, see CheckVariables
+	varErrors   []error
+	blockErrors []error
+}
+
+// CheckVariables reports the __var declarations that could not be parsed
+// and __if blocks that are malformed or test undeclared variables
+func (m *SnippetMetadata) CheckVariables() error {
+	var msgs []string
+	if len(m.varErrors) > 0 {
+		msgs = append(msgs, "invalid __var declaration: "+joinErrors(m.varErrors))
+	}
+	if len(m.blockErrors) > 0 {
+		msgs = append(msgs, "invalid __if block: "+joinErrors(m.blockErrors))
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+func joinErrors(errs []error) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
 }
 
 // PromptCommonMetadata prompts user for common metadata fields
@@ -55,7 +87,14 @@ func PromptCommonMetadata(defaultName string, skipPrompts bool) (CommonMetadata,
 
 // getGitAuthor fetches git user name
 func getGitAuthor() string {
-	cmd := exec.Command("git", "config", "user.name")
+	return gitConfig("", "user.name")
+}
+
+// gitConfig returns a git config value as seen from dir, or "" if it is
+// not set or git is missing
+func gitConfig(dir, key string) string {
+	cmd := exec.Command("git", "config", key)
+	cmd.Dir = dir
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -71,20 +110,24 @@ func ParseSnippetMetadata(filePath string) (*SnippetMetadata, error) {
 	}
 	defer file.Close()
 
-	meta := &SnippetMetadata{
-		Variables: make(map[string]string),
-	}
+	meta := &SnippetMetadata{}
 
 	scanner := bufio.NewScanner(file)
 	
 	// Regex patterns for metadata
-	authorRe := regexp.MustCompile(`__author\s+(.+)`)
-	descRe := regexp.MustCompile(`__desc\s+(.+)`)
-	versionRe := regexp.MustCompile(`__version\s+(.+)`)
-	// Match variable names with underscores (e.g., bl__API_URL)
-	varRe := regexp.MustCompile(`__var\s+([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*(.+)`)
+	authorRe := regexp.MustCompile(`__author\s+(.+?)\s*(?:\*/|-->)?$`)
+	descRe := regexp.MustCompile(`__desc\s+(.+?)\s*(?:\*/|-->)?$`)
+	versionRe := regexp.MustCompile(`__version\s+(.+?)\s*(?:\*/|-->)?$`)
+
+	// Conditions are checked against the declarations once all are read
+	type tested struct {
+		cond Condition
+		line int
+	}
+	var b blocks
+	var conds []tested
 
-	for scanner.Scan() {
+	for n := 1; scanner.Scan(); n++ {
 		line := strings.TrimSpace(scanner.Text())
 		
 		// Skip empty lines
@@ -102,10 +145,40 @@ func ParseSnippetMetadata(filePath string) (*SnippetMetadata, error) {
 		if matches := versionRe.FindStringSubmatch(line); len(matches) > 1 {
 			meta.Version = strings.TrimSpace(matches[1])
 		}
-		if matches := varRe.FindStringSubmatch(line); len(matches) > 2 {
-			varName := strings.TrimSpace(matches[1])
-			varValue := strings.TrimSpace(matches[2])
-			meta.Variables[varName] = varValue
+		for _, name := range builtinRe.FindAllString(line, -1) {
+			if !slices.Contains(meta.Builtins, name) {
+				meta.Builtins = append(meta.Builtins, name)
+			}
+		}
+
+		if kind, expr, ok := ParseDirective(line); ok {
+			err := b.apply(kind, expr, n, func(c Condition) (bool, error) {
+				conds = append(conds, tested{c, n})
+				return true, nil
+			})
+			if err != nil {
+				meta.blockErrors = append(meta.blockErrors, err)
+			}
+			continue
+		}
+		if v, ok, err := ParseVariable(line); ok {
+			if err != nil {
+				meta.varErrors = append(meta.varErrors, err)
+				continue
+			}
+			// A computed default can only use what is known before it
+			for _, ref := range v.References() {
+				earlier := slices.ContainsFunc(meta.Variables, func(o Variable) bool { return o.Name == ref })
+				if !earlier && !IsBuiltin(ref) {
+					meta.varErrors = append(meta.varErrors, fmt.Errorf("%s: default uses %s, which is not declared before it", v.Name, ref))
+				}
+			}
+			// A later declaration of the same name wins
+			if i := slices.IndexFunc(meta.Variables, func(o Variable) bool { return o.Name == v.Name }); i >= 0 {
+				meta.Variables[i] = v
+			} else {
+				meta.Variables = append(meta.Variables, v)
+			}
 		}
 	}
 
@@ -113,6 +186,30 @@ func ParseSnippetMetadata(filePath string) (*SnippetMetadata, error) {
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
 
+	if err := b.end(); err != nil {
+		meta.blockErrors = append(meta.blockErrors, err)
+	}
+	meta.Builtins = slices.DeleteFunc(meta.Builtins, func(name string) bool {
+		return slices.ContainsFunc(meta.Variables, func(v Variable) bool { return v.Name == name })
+	})
+	for _, t := range conds {
+		i := slices.IndexFunc(meta.Variables, func(v Variable) bool { return v.Name == t.cond.Name })
+		if i < 0 && IsBuiltin(t.cond.Name) {
+			continue
+		}
+		if i < 0 {
+			meta.blockErrors = append(meta.blockErrors, fmt.Errorf("line %d: %s is not declared with __var", t.line, t.cond.Name))
+			continue
+		}
+		if v := meta.Variables[i]; v.Type == VarEnum {
+			for _, opt := range t.cond.Options {
+				if _, err := v.Check(opt); err != nil {
+					meta.blockErrors = append(meta.blockErrors, fmt.Errorf("line %d: %s: %w", t.line, v.Name, err))
+				}
+			}
+		}
+	}
+
 	return meta, nil
 }
 
@@ -3,15 +3,19 @@ package utils
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
 
+// stdin is shared by all prompts so buffered input meant for a later
+// prompt is not lost when several prompts read piped input
+var stdin = bufio.NewReader(os.Stdin)
+
 func Prompt(message string) (string, error) {
 	fmt.Print(message)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
+	input, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || input == "") {
 		return "", err
 	}
 	return strings.TrimSpace(input), nil
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Transforms change a variable's value where it is written with pipes,
+// bl__ENTITY|pascal. Pipes chain from left to right: bl__ENTITY|plural|kebab.
+var Transforms = map[string]func(string) string{
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"pascal":   func(s string) string { return joinWords(s, "", titleWord, titleWord) },
+	"camel":    func(s string) string { return joinWords(s, "", strings.ToLower, titleWord) },
+	"snake":    func(s string) string { return joinWords(s, "_", strings.ToLower, strings.ToLower) },
+	"kebab":    func(s string) string { return joinWords(s, "-", strings.ToLower, strings.ToLower) },
+	"constant": func(s string) string { return joinWords(s, "_", strings.ToUpper, strings.ToUpper) },
+	"title":    func(s string) string { return joinWords(s, " ", titleWord, titleWord) },
+	"plural":   Pluralize,
+}
+
+// TransformNames returns the names of the transforms, sorted
+func TransformNames() []string {
+	names := make([]string, 0, len(Transforms))
+	for name := range Transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// variableReplacer replaces variable references and their transforms
+type variableReplacer struct {
+	re     *regexp.Regexp
+	values map[string]string
+}
+
+func newVariableReplacer(values map[string]string) *variableReplacer {
+	if len(values) == 0 {
+		return &variableReplacer{}
+	}
+
+	// Longest names first, so bl__API does not match the start of bl__API_URL
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	pattern := `(` + strings.Join(names, "|") + `)((?:\|(?:` + strings.Join(TransformNames(), "|") + `)\b)*)`
+	return &variableReplacer{re: regexp.MustCompile(pattern), values: values}
+}
+
+// replace writes the value of every variable in s, transformed as its
+// pipes say. A pipe that names no transform is left alone.
+func (r *variableReplacer) replace(s string) string {
+	if r.re == nil {
+		return s
+	}
+	return r.re.ReplaceAllStringFunc(s, func(ref string) string {
+		m := r.re.FindStringSubmatch(ref)
+		value := r.values[m[1]]
+		for _, name := range strings.Split(m[2], "|")[1:] {
+			value = Transforms[name](value)
+		}
+		return value
+	})
+}
+
+// words splits a value into words at separators, at lower-to-upper case
+// changes and at the end of acronyms: "HTTPServer user_id" gives HTTP,
+// Server, user, id.
+func words(s string) []string {
+	var out []string
+	var cur []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(cur) > 0 {
+				out = append(out, string(cur))
+				cur = nil
+			}
+			continue
+		}
+		if len(cur) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				out = append(out, string(cur))
+				cur = nil
+			}
+		}
+		cur = append(cur, r)
+	}
+	if len(cur) > 0 {
+		out = append(out, string(cur))
+	}
+	return out
+}
+
+func joinWords(s, sep string, first, rest func(string) string) string {
+	ws := words(s)
+	for i, w := range ws {
+		if i == 0 {
+			ws[i] = first(w)
+		} else {
+			ws[i] = rest(w)
+		}
+	}
+	return strings.Join(ws, sep)
+}
+
+func titleWord(w string) string {
+	runes := []rune(strings.ToLower(w))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// irregularPlurals covers common words the suffix rules get wrong
+var irregularPlurals = map[string]string{
+	"person": "people", "child": "children", "man": "men", "woman": "women",
+	"mouse": "mice", "goose": "geese", "foot": "feet", "tooth": "teeth",
+	"leaf": "leaves", "life": "lives", "knife": "knives", "wife": "wives",
+	"datum": "data", "index": "indices", "matrix": "matrices", "criterion": "criteria",
+	"data": "data", "info": "info", "metadata": "metadata", "news": "news",
+	"series": "series", "species": "species", "sheep": "sheep", "fish": "fish",
+}
+
+// Pluralize returns the English plural of the last word of s, keeping
+// the rest and the word's case: UserProfile gives UserProfiles, USER_CATEGORY
+// gives USER_CATEGORIES.
+func Pluralize(s string) string {
+	ws := words(s)
+	if len(ws) == 0 || !strings.HasSuffix(s, ws[len(ws)-1]) {
+		return s
+	}
+	last := ws[len(ws)-1]
+	prefix := s[:len(s)-len(last)]
+	lower := strings.ToLower(last)
+
+	plural, ok := irregularPlurals[lower]
+	if !ok {
+		switch {
+		case strings.HasSuffix(lower, "s") || strings.HasSuffix(lower, "x") || strings.HasSuffix(lower, "z") ||
+			strings.HasSuffix(lower, "ch") || strings.HasSuffix(lower, "sh"):
+			plural = lower + "es"
+		case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+			plural = lower[:len(lower)-1] + "ies"
+		default:
+			plural = lower + "s"
+		}
+	}
+
+	// Keep the word's case: USER, User or user
+	switch {
+	case last == strings.ToUpper(last) && len(last) > 1:
+		plural = strings.ToUpper(plural)
+	case unicode.IsUpper([]rune(last)[0]):
+		plural = titleWord(plural)
+	}
+	return prefix + plural
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		transform, in, want string
+	}{
+		{"pascal", "user profile", "UserProfile"},
+		{"pascal", "user_profile", "UserProfile"},
+		{"camel", "UserProfile", "userProfile"},
+		{"snake", "UserProfile", "user_profile"},
+		{"snake", "HTTPServer", "http_server"},
+		{"kebab", "userProfile", "user-profile"},
+		{"constant", "user-profile", "USER_PROFILE"},
+		{"title", "user_profile", "User Profile"},
+		{"upper", "userProfile", "USERPROFILE"},
+		{"lower", "UserProfile", "userprofile"},
+		{"plural", "UserProfile", "UserProfiles"},
+		{"plural", "category", "categories"},
+		{"plural", "USER_ADDRESS", "USER_ADDRESSES"},
+		{"plural", "Person", "People"},
+		{"plural", "key", "keys"},
+	}
+	for _, tt := range tests {
+		if got := Transforms[tt.transform](tt.in); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.transform, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTransforms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.ts")
+	content := "// __var bl__ENTITY = user profile\n" +
+		"export class bl__ENTITY|pascal {}\n" +
+		"const bl__ENTITY|camel = new bl__ENTITY|pascal();\n" +
+		"const TABLE = \"bl__ENTITY|plural|snake\"; // bl__ENTITY|constant\n" +
+		"const url = \"/bl__ENTITY|kebab|plural\";\n" +
+		"const mask = bl__API|other; const base = bl__API_URL;\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := RenderFileWithVariables(path, map[string]string{
+		"bl__ENTITY":  "user profile",
+		"bl__API":     "api",
+		"bl__API_URL": "http://x",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "export class UserProfile {}\n" +
+		"const userProfile = new UserProfile();\n" +
+		"const TABLE = \"user_profiles\"; // USER_PROFILE\n" +
+		"const url = \"/user-profiles\";\n" +
+		"const mask = api|other; const base = http://x;\n"
+	if string(got) != want {
+		t.Errorf("rendered\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// VarEnvPrefix marks environment variables that set template variables:
+// BL_VAR_API_URL sets bl__API_URL
+const VarEnvPrefix = "BL_VAR_"
+
+// VariableName maps a key given on the command line, in a values file or in
+// the environment to the template variable it sets. BL_VAR_X becomes bl__X;
+// other keys are used as they are.
+func VariableName(key string) string {
+	if rest, ok := strings.CutPrefix(key, VarEnvPrefix); ok && rest != "" {
+		return "bl__" + rest
+	}
+	return key
+}
+
+// ReadVariableValues reads template variable values from a file: a JSON
+// object when the name ends in .json, KEY=value lines (.env) otherwise
+func ReadVariableValues(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]string
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		values, err = parseJSONValues(data)
+	} else {
+		values, err = parseEnvValues(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// parseJSONValues accepts an object of strings, numbers and booleans
+func parseJSONValues(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of values: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[key] = v
+		case json.Number:
+			values[key] = v.String()
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("value of '%s' must be a string, number or boolean", key)
+		}
+	}
+	return values, nil
+}
+
+// parseEnvValues reads KEY=value lines. Blank lines, # comments and a
+// leading "export" are skipped, and quotes around a value are removed.
+func parseEnvValues(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=value", n)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			// An unquoted value ends at a comment
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadVariableValues(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"vars.json": `{"bl__API_URL": "https://api.example.com", "bl__PORT": 8080, "bl__DEBUG": true}`,
+		".env": "# values\nexport BL_VAR_API_URL=https://api.example.com # prod\n" +
+			"bl__PORT='8080'\nbl__DEBUG=\"true\"\n\n",
+	}
+	want := map[string]string{"bl__API_URL": "https://api.example.com", "bl__PORT": "8080", "bl__DEBUG": "true"}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		values, err := ReadVariableValues(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got := make(map[string]string)
+		for key, value := range values {
+			got[VariableName(key)] = value
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+		for key, value := range want {
+			if got[key] != value {
+				t.Errorf("%s: %s = %q, want %q", name, key, got[key], value)
+			}
+		}
+	}
+}
+
+func TestReadVariableValuesErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"nested.json": `{"bl__X": {"a": 1}}`,
+		"list.json":   `["bl__X"]`,
+		"bad.env":     "bl__X\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadVariableValues(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Variable types a __var declaration can name
+const (
+	VarString = "string"
+	VarInt    = "int"
+	VarBool   = "bool"
+	VarEnum   = "enum"
+	VarURL    = "url"
+	VarPath   = "path"
+)
+
+// Variable is a template variable declared in a snippet:
+//
+//	__var bl__NAME[:type[(args)]] [= default] [-- description]
+//
+// The type defaults to string. enum takes its options, enum(dev|prod), and
+// string an optional regular expression, string(/^[a-z]+$/). A variable
+// without "= default" is required. A default can be computed from variables
+// declared before it, or reserved ones: = {{bl__ENTITY|kebab}}-service.
+type Variable struct {
+	Name        string
+	Type        string
+	Options     []string
+	Pattern     *regexp.Regexp
+	Default     string
+	HasDefault  bool
+	Description string
+}
+
+var (
+	varDeclRe  = regexp.MustCompile(`__var\s+([a-zA-Z_][a-zA-Z0-9_]*)(.*)$`)
+	varTypeRe  = regexp.MustCompile(`^:([a-z]+)`)
+	varDescRe  = regexp.MustCompile(`(?:^|\s)--(?:\s+|$)`)
+	templateRe = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+)
+
+// ParseVariable parses the __var declaration in line. It reports false when
+// the line declares no variable, and an error when the declaration is
+// malformed.
+func ParseVariable(line string) (Variable, bool, error) {
+	m := varDeclRe.FindStringSubmatch(line)
+	if m == nil {
+		return Variable{}, false, nil
+	}
+	v := Variable{Name: m[1], Type: VarString}
+	// Block comments close on the same line in CSS and HTML snippets
+	rest := strings.TrimRight(m[2], " \t\r")
+	for _, end := range []string{"*/", "-->"} {
+		rest = strings.TrimSuffix(rest, end)
+	}
+
+	// Old declarations were "__var NAME = value" with nothing else; the
+	// type only counts directly after the name
+	if tm := varTypeRe.FindStringSubmatch(rest); tm != nil {
+		v.Type = tm[1]
+		rest = rest[len(tm[0]):]
+		if strings.HasPrefix(rest, "(") {
+			args, n, err := typeArgs(rest)
+			if err != nil {
+				return v, true, fmt.Errorf("%s: %w", v.Name, err)
+			}
+			rest = rest[n:]
+			if err := v.setArgs(args); err != nil {
+				return v, true, fmt.Errorf("%s: %w", v.Name, err)
+			}
+		}
+	}
+	if !slices.Contains([]string{VarString, VarInt, VarBool, VarEnum, VarURL, VarPath}, v.Type) {
+		return v, true, fmt.Errorf("%s: unknown type '%s'", v.Name, v.Type)
+	}
+	if v.Type == VarEnum && len(v.Options) == 0 {
+		return v, true, fmt.Errorf("%s: enum needs its options, e.g. enum(dev|prod)", v.Name)
+	}
+
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "=") {
+		rest = strings.TrimSpace(rest[1:])
+		v.HasDefault = true
+		v.Default = rest
+		if loc := varDescRe.FindStringIndex(rest); loc != nil {
+			v.Default = strings.TrimSpace(rest[:loc[0]])
+			v.Description = strings.TrimSpace(rest[loc[1]:])
+		}
+	} else if loc := varDescRe.FindStringIndex(rest); loc != nil && strings.TrimSpace(rest[:loc[0]]) == "" {
+		v.Description = strings.TrimSpace(rest[loc[1]:])
+	} else if rest != "" {
+		return v, true, fmt.Errorf("%s: unexpected '%s'", v.Name, rest)
+	}
+
+	if v.Computed() {
+		// Checked once the variables it uses have values
+		for _, m := range templateRe.FindAllStringSubmatch(v.Default, -1) {
+			if _, _, err := parseTemplate(m[1]); err != nil {
+				return v, true, fmt.Errorf("%s: default %w", v.Name, err)
+			}
+		}
+	} else if v.HasDefault && v.Default != "" {
+		value, err := v.Check(v.Default)
+		if err != nil {
+			return v, true, fmt.Errorf("%s: default %w", v.Name, err)
+		}
+		v.Default = value
+	}
+	return v, true, nil
+}
+
+// typeArgs returns the arguments in the parentheses s starts with and the
+// length consumed. A regular expression, (/.../), ends at the first "/)"
+// that closes the declaration's type.
+func typeArgs(s string) (string, int, error) {
+	if strings.HasPrefix(s, "(/") {
+		for i := 2; i+1 < len(s); i++ {
+			if s[i] == '/' && s[i+1] == ')' && (i+2 == len(s) || s[i+2] == ' ' || s[i+2] == '\t' || s[i+2] == '=') {
+				return s[1 : i+1], i + 2, nil
+			}
+		}
+		return "", 0, fmt.Errorf("unterminated pattern, expected /)")
+	}
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return "", 0, fmt.Errorf("missing ')'")
+	}
+	return s[1:end], end + 1, nil
+}
+
+func (v *Variable) setArgs(args string) error {
+	switch v.Type {
+	case VarEnum:
+		for _, opt := range strings.Split(args, "|") {
+			if opt = strings.TrimSpace(opt); opt != "" {
+				v.Options = append(v.Options, opt)
+			}
+		}
+	case VarString:
+		if len(args) < 2 || args[0] != '/' || args[len(args)-1] != '/' {
+			return fmt.Errorf("string takes a pattern, e.g. string(/^[a-z]+$/)")
+		}
+		re, err := regexp.Compile(args[1 : len(args)-1])
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		v.Pattern = re
+	default:
+		return fmt.Errorf("type %s takes no arguments", v.Type)
+	}
+	return nil
+}
+
+// parseTemplate parses the inside of {{ }}: a variable name followed by
+// transforms, bl__ENTITY|plural|kebab
+func parseTemplate(expr string) (string, []string, error) {
+	parts := strings.Split(expr, "|")
+	name := strings.TrimSpace(parts[0])
+	if !varNameRe.MatchString(name) {
+		return "", nil, fmt.Errorf("'{{%s}}' does not name a variable", expr)
+	}
+	var transforms []string
+	for _, t := range parts[1:] {
+		t = strings.TrimSpace(t)
+		if _, ok := Transforms[t]; !ok {
+			return "", nil, fmt.Errorf("'{{%s}}': unknown transform '%s', use one of %s", expr, t, strings.Join(TransformNames(), ", "))
+		}
+		transforms = append(transforms, t)
+	}
+	return name, transforms, nil
+}
+
+// Computed reports whether the default is computed from other variables
+func (v Variable) Computed() bool {
+	return v.HasDefault && templateRe.MatchString(v.Default)
+}
+
+// References returns the variables a computed default uses
+func (v Variable) References() []string {
+	var names []string
+	for _, m := range templateRe.FindAllStringSubmatch(v.Default, -1) {
+		if name, _, err := parseTemplate(m[1]); err == nil && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ExpandDefault returns the default with every {{...}} replaced by the
+// transformed value from values
+func (v Variable) ExpandDefault(values map[string]string) string {
+	return templateRe.ReplaceAllStringFunc(v.Default, func(t string) string {
+		name, transforms, err := parseTemplate(t[2 : len(t)-2])
+		if err != nil {
+			return t
+		}
+		value := values[name]
+		for _, tr := range transforms {
+			value = Transforms[tr](value)
+		}
+		return value
+	})
+}
+
+// Required reports whether a value must be given
+func (v Variable) Required() bool {
+	return !v.HasDefault
+}
+
+// TypeLabel describes the accepted values for prompts, e.g. "int" or
+// "dev|prod". It is empty for plain strings.
+func (v Variable) TypeLabel() string {
+	switch {
+	case v.Type == VarEnum:
+		return strings.Join(v.Options, "|")
+	case v.Pattern != nil:
+		return "/" + v.Pattern.String() + "/"
+	case v.Type == VarString:
+		return ""
+	}
+	return v.Type
+}
+
+// Check validates value against the variable's type and returns it in
+// canonical form: bools as true or false, enum options as declared
+func (v Variable) Check(value string) (string, error) {
+	if value == "" {
+		if v.Required() {
+			return "", fmt.Errorf("a value is required")
+		}
+		return value, nil
+	}
+
+	switch v.Type {
+	case VarInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("'%s' is not an integer", value)
+		}
+	case VarBool:
+		switch strings.ToLower(value) {
+		case "true", "yes", "y", "1", "on":
+			return "true", nil
+		case "false", "no", "n", "0", "off":
+			return "false", nil
+		}
+		return "", fmt.Errorf("'%s' is not a boolean, use true or false", value)
+	case VarEnum:
+		for _, opt := range v.Options {
+			if strings.EqualFold(opt, value) {
+				return opt, nil
+			}
+		}
+		return "", fmt.Errorf("'%s' is not one of %s", value, strings.Join(v.Options, ", "))
+	case VarURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "", fmt.Errorf("'%s' is not an absolute URL", value)
+		}
+	case VarPath:
+		if strings.ContainsRune(value, 0) {
+			return "", fmt.Errorf("'%s' is not a valid path", value)
+		}
+	case VarString:
+		if v.Pattern != nil && !v.Pattern.MatchString(value) {
+			return "", fmt.Errorf("'%s' does not match /%s/", value, v.Pattern)
+		}
+	}
+	return value, nil
+}
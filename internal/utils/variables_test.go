@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVariable(t *testing.T) {
+	tests := []struct {
+		line     string
+		want     Variable
+		wantErr  string
+		declared bool
+	}{
+		{line: "// __var bl__API_URL = http://localhost:3000", declared: true,
+			want: Variable{Name: "bl__API_URL", Type: VarString, Default: "http://localhost:3000", HasDefault: true}},
+		{line: "# __var bl__PORT:int = 3000 -- Port the server listens on", declared: true,
+			want: Variable{Name: "bl__PORT", Type: VarInt, Default: "3000", HasDefault: true, Description: "Port the server listens on"}},
+		{line: "// __var bl__ENV:enum(dev|prod) = PROD", declared: true,
+			want: Variable{Name: "bl__ENV", Type: VarEnum, Options: []string{"dev", "prod"}, Default: "prod", HasDefault: true}},
+		{line: "// __var bl__DEBUG:bool = yes", declared: true,
+			want: Variable{Name: "bl__DEBUG", Type: VarBool, Default: "true", HasDefault: true}},
+		{line: "// __var bl__NAME -- Service name", declared: true,
+			want: Variable{Name: "bl__NAME", Type: VarString, Description: "Service name"}},
+		{line: "// __var bl__EMPTY =", declared: true,
+			want: Variable{Name: "bl__EMPTY", Type: VarString, HasDefault: true}},
+		{line: "// __var bl__PORT:int = abc", declared: true, wantErr: "not an integer"},
+		{line: "// __var bl__X:color", declared: true, wantErr: "unknown type"},
+		{line: "// __var bl__X:enum()", declared: true, wantErr: "needs its options"},
+		{line: "// __var bl__X:string(/[/)", declared: true, wantErr: "invalid pattern"},
+		{line: "/* __var bl__PORT:int = 3000 */", declared: true,
+			want: Variable{Name: "bl__PORT", Type: VarInt, Default: "3000", HasDefault: true}},
+		{line: "/* __var bl__NAME */", declared: true,
+			want: Variable{Name: "bl__NAME", Type: VarString}},
+		{line: "<!-- __var bl__N -- Title -->", declared: true,
+			want: Variable{Name: "bl__N", Type: VarString, Description: "Title"}},
+		{line: "/* __var bl__COLOR:string(/^#[0-9a-f]{6}$/) = #ffffff -- Brand color */", declared: true,
+			want: Variable{Name: "bl__COLOR", Type: VarString, Default: "#ffffff", HasDefault: true, Description: "Brand color"}},
+		{line: "const x = 1", declared: false},
+	}
+
+	for _, tt := range tests {
+		got, ok, err := ParseVariable(tt.line)
+		if ok != tt.declared {
+			t.Errorf("ParseVariable(%q) declared = %v, want %v", tt.line, ok, tt.declared)
+			continue
+		}
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseVariable(%q) error = %v, want %q", tt.line, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseVariable(%q) error = %v", tt.line, err)
+			continue
+		}
+		if got.Name != tt.want.Name || got.Type != tt.want.Type || got.Default != tt.want.Default ||
+			got.HasDefault != tt.want.HasDefault || got.Description != tt.want.Description ||
+			strings.Join(got.Options, "|") != strings.Join(tt.want.Options, "|") {
+			t.Errorf("ParseVariable(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestVariableCheck(t *testing.T) {
+	slug, _, err := ParseVariable(`// __var bl__SLUG:string(/^[a-z-]+$/) -- URL slug`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slug.Required() {
+		t.Fatal("variable without default is not required")
+	}
+	for value, valid := range map[string]bool{"my-app": true, "My App": false, "": false} {
+		if _, err := slug.Check(value); (err == nil) != valid {
+			t.Errorf("Check(%q) error = %v, want valid %v", value, err, valid)
+		}
+	}
+
+	site := Variable{Name: "bl__SITE", Type: VarURL}
+	if _, err := site.Check("example.com"); err == nil {
+		t.Error("url accepted a value without scheme")
+	}
+	if _, err := site.Check("https://example.com/api"); err != nil {
+		t.Errorf("url rejected a valid value: %v", err)
+	}
+}
+
+func TestParseSnippetMetadataVariables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.js")
+	content := "// __author me\n// __var bl__PORT:int = 3000\n// __var bl__HOST = localhost\n// __var bl__BAD:int = x\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := ParseSnippetMetadata(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.Variables) != 2 || meta.Variables[0].Name != "bl__PORT" || meta.Variables[1].Name != "bl__HOST" {
+		t.Fatalf("Variables = %+v, want PORT and HOST in order", meta.Variables)
+	}
+	if err := meta.CheckVariables(); err == nil || !strings.Contains(err.Error(), "bl__BAD") {
+		t.Fatalf("CheckVariables() = %v, want error naming bl__BAD", err)
+	}
+}
+
+func TestBlockCommentDeclarations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "card.html")
+	content := "<!-- __author me -->\n<!-- __var bl__TITLE -- Card title -->\n" +
+		"<style>\n/* __var bl__WIDTH:int = 320 */\n.card { width: bl__WIDTHpx; }\n</style>\n<h2>bl__TITLE</h2>\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := ParseSnippetMetadata(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := meta.CheckVariables(); err != nil {
+		t.Fatalf("CheckVariables() error = %v", err)
+	}
+	if meta.Author != "me" {
+		t.Errorf("Author = %q, want %q", meta.Author, "me")
+	}
+
+	got, err := RenderFileWithVariables(path, map[string]string{"bl__TITLE": "Hello", "bl__WIDTH": "320"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<style>\n.card { width: 320px; }\n</style>\n<h2>Hello</h2>\n"
+	if string(got) != want {
+		t.Errorf("rendered\n%s\nwant\n%s", got, want)
+	}
+}
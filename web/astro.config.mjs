@@ -38,6 +38,7 @@ export default defineConfig({
 					label: 'Guides',
 					items: [
 						{ label: 'Boiler Syntax', slug: 'guides/syntax' },
+						{ label: 'Registry', slug: 'guides/registry' },
 					],
 				},
 				{